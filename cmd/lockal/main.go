@@ -1,9 +1,15 @@
 package main
 
 import (
+	"archive/tar"
+	"archive/zip"
 	"fmt"
+	"io"
+	"net/http"
 	"os"
 	"path/filepath"
+	"strings"
+	"time"
 
 	"github.com/apex/log"
 	cliHandler "github.com/apex/log/handlers/cli"
@@ -12,8 +18,20 @@ import (
 	"github.com/spf13/afero"
 	"github.com/urfave/cli/v2"
 
+	"github.com/dustinspecker/lockal/internal/bundle"
+	"github.com/dustinspecker/lockal/internal/cache"
+	"github.com/dustinspecker/lockal/internal/checksum"
 	"github.com/dustinspecker/lockal/internal/config"
+	"github.com/dustinspecker/lockal/internal/dependency"
+	"github.com/dustinspecker/lockal/internal/filelock"
+	"github.com/dustinspecker/lockal/internal/globalconfig"
+	"github.com/dustinspecker/lockal/internal/install"
+	"github.com/dustinspecker/lockal/internal/lock"
 	"github.com/dustinspecker/lockal/internal/parse"
+	"github.com/dustinspecker/lockal/internal/platform"
+	"github.com/dustinspecker/lockal/internal/redact"
+	"github.com/dustinspecker/lockal/internal/state"
+	"github.com/dustinspecker/lockal/internal/verify"
 )
 
 var (
@@ -22,7 +40,7 @@ var (
 
 func main() {
 	log.SetLevel(log.InfoLevel)
-	log.SetHandler(cliHandler.New(os.Stderr))
+	log.SetHandler(redact.NewHandler(cliHandler.New(os.Stderr)))
 
 	logCtx := log.WithFields(log.Fields{
 		"app": "lockal",
@@ -33,6 +51,48 @@ func main() {
 		logCtx.WithError(err).Fatal("getting home directory")
 	}
 
+	cacheDirectoryFlag := &cli.StringFlag{
+		Name:    "cache-directory",
+		Usage:   "where to save cached downloads",
+		Value:   filepath.Join(userHomeDir, ".cache"),
+		EnvVars: []string{"XDG_CACHE_DIR"},
+	}
+
+	platformFlag := &cli.StringFlag{
+		Name:    "platform",
+		Usage:   "os/arch to evaluate lockal.star for, such as darwin/arm64",
+		Value:   platform.Current(),
+		EnvVars: []string{"LOCKAL_PLATFORM"},
+	}
+
+	prefixFlag := &cli.StringFlag{
+		Name:  "prefix",
+		Usage: "directory to install into (defaults to .lockal/platforms/OS-ARCH when --platform isn't the current platform)",
+	}
+
+	workingDir, err := os.Getwd()
+	if err != nil {
+		logCtx.WithError(err).Fatal("getting working directory")
+	}
+
+	globalConfig := globalconfig.Config{}
+
+	newConfig := func(c *cli.Context) config.Config {
+		return config.Config{
+			CacheDir:               c.String("cache-directory"),
+			Project:                workingDir,
+			Fs:                     afero.NewOsFs(),
+			LogCtx:                 logCtx,
+			GetFile:                globalConfig.WrapGetFile(logCtx, newGetFile(globalConfig)),
+			ExtractFileFromArchive: extractFileFromArchive,
+			ListFilesInArchive:     listFilesInArchive,
+			LockFile:               filelock.Lock,
+			RemoteCache:            c.String("remote-cache"),
+			RemoteCacheUpload:      c.Bool("remote-cache-upload"),
+			PutFile:                newPutFile(globalConfig),
+		}
+	}
+
 	app := &cli.App{
 		Name:  "lockal",
 		Usage: "manage binary dependencies",
@@ -43,6 +103,12 @@ func main() {
 				Value:  "info",
 				Hidden: false,
 			},
+			&cli.StringFlag{
+				Name:    "config",
+				Usage:   "path to lockal's global configuration of URL rewrites and mirrors",
+				Value:   globalconfig.GetDefaultPath(userHomeDir),
+				EnvVars: []string{"LOCKAL_CONFIG"},
+			},
 		},
 		Before: func(c *cli.Context) error {
 			logLevel, err := log.ParseLevel(c.String("log-level"))
@@ -52,61 +118,464 @@ func main() {
 
 			log.SetLevel(logLevel)
 
+			globalConfig, err = globalconfig.Read(afero.NewOsFs(), c.String("config"))
+			if err != nil {
+				return err
+			}
+
+			addSecrets(globalConfig)
+
 			return nil
 		},
 		Commands: []*cli.Command{
+			{
+				Name:  "bundle",
+				Usage: "export files needed by lockal.star for installing without network access",
+				Subcommands: []*cli.Command{
+					{
+						Name:      "create",
+						Usage:     "write every file needed to install lockal.star on each platform to a tar",
+						ArgsUsage: "OUT",
+						Flags: []cli.Flag{
+							cacheDirectoryFlag,
+							&cli.StringSliceFlag{
+								Name:  "platform",
+								Usage: "os/arch to bundle, may be provided multiple times",
+								Value: cli.NewStringSlice(lock.DefaultPlatforms...),
+							},
+						},
+						Action: func(c *cli.Context) error {
+							if c.NArg() != 1 {
+								return fmt.Errorf("expected exactly one output file, but got %d", c.NArg())
+							}
+
+							out, err := os.Create(c.Args().First())
+							if err != nil {
+								return err
+							}
+
+							err = bundle.Create(newConfig(c), out, c.StringSlice("platform"))
+							if closeErr := out.Close(); err == nil {
+								err = closeErr
+							}
+
+							if err != nil {
+								os.Remove(c.Args().First())
+
+								return err
+							}
+
+							logCtx.Info(fmt.Sprintf("wrote %s", c.Args().First()))
+
+							return nil
+						},
+					},
+				},
+			},
+			{
+				Name:  "cache",
+				Usage: "manage the cache of downloads",
+				Subcommands: []*cli.Command{
+					{
+						Name:  "gc",
+						Usage: "remove cache entries not referenced by lockal.star",
+						Flags: []cli.Flag{
+							cacheDirectoryFlag,
+							platformFlag,
+							&cli.StringFlag{
+								Name:  "max-size",
+								Usage: "remove least recently used entries until the cache is at most this size, such as 500MB",
+							},
+							&cli.StringFlag{
+								Name:  "older-than",
+								Usage: "remove entries not used within this age, such as 30d or 12h",
+							},
+						},
+						Action: func(c *cli.Context) error {
+							// a value of 0, such as --older-than 0s, is still a policy, so check the flags rather than the parsed values
+							if !c.IsSet("max-size") && !c.IsSet("older-than") {
+								return fmt.Errorf("at least one of --max-size or --older-than must be provided")
+							}
+
+							policy := cache.GCPolicy{}
+
+							if c.IsSet("max-size") {
+								maxSize, err := cache.ParseSize(c.String("max-size"))
+								if err != nil {
+									return err
+								}
+
+								policy.MaxSize = &maxSize
+							}
+
+							if c.IsSet("older-than") {
+								olderThan, err := cache.ParseAge(c.String("older-than"))
+								if err != nil {
+									return err
+								}
+
+								policy.OlderThan = &olderThan
+							}
+
+							cfg := newConfig(c)
+
+							deps, err := getDependencies(cfg, c.String("platform"))
+							if err != nil {
+								return err
+							}
+
+							return cache.GC(cfg.Fs, logCtx, cfg.CacheDir, cfg.LockFile, deps, policy, time.Now())
+						},
+					},
+					{
+						Name:  "ls",
+						Usage: "list cache entries with their size, when they were last used, and by which project and rule",
+						Flags: []cli.Flag{
+							cacheDirectoryFlag,
+						},
+						Action: func(c *cli.Context) error {
+							entries, err := cache.List(afero.NewReadOnlyFs(afero.NewOsFs()), c.String("cache-directory"))
+							if err != nil {
+								return err
+							}
+
+							return cache.Print(os.Stdout, entries)
+						},
+					},
+					{
+						Name:  "serve",
+						Usage: "serve the cache read-only over HTTP for other machines to use as a remote cache",
+						Flags: []cli.Flag{
+							cacheDirectoryFlag,
+							&cli.StringFlag{
+								Name:  "listen",
+								Usage: "address to listen on, use :8080 to listen on every interface",
+								Value: "127.0.0.1:8080",
+							},
+						},
+						Action: func(c *cli.Context) error {
+							handler := cache.NewHandler(afero.NewReadOnlyFs(afero.NewOsFs()), logCtx, c.String("cache-directory"))
+
+							logCtx.Info(fmt.Sprintf("serving %s on %s", filepath.Join(c.String("cache-directory"), "lockal"), c.String("listen")))
+
+							return http.ListenAndServe(c.String("listen"), handler)
+						},
+					},
+					{
+						Name:  "verify",
+						Usage: "re-hash cache entries and remove any that are corrupt",
+						Flags: []cli.Flag{
+							cacheDirectoryFlag,
+						},
+						Action: func(c *cli.Context) error {
+							return cache.Verify(afero.NewOsFs(), logCtx, c.String("cache-directory"), filelock.Lock)
+						},
+					},
+				},
+			},
+			{
+				Name:      "checksum",
+				Usage:     "download a file and print a rule for it to add to lockal.star",
+				ArgsUsage: "LOCATION",
+				Flags: []cli.Flag{
+					cacheDirectoryFlag,
+					&cli.StringFlag{
+						Name:  "name",
+						Usage: "name of the rule to print (defaults to bin/ followed by the file name)",
+					},
+					&cli.StringFlag{
+						Name:  "extract-filepath",
+						Usage: "file within an archive to print an executable_from_archive rule for",
+					},
+				},
+				Action: func(c *cli.Context) error {
+					if c.NArg() != 1 {
+						return fmt.Errorf("expected exactly one location, but got %d", c.NArg())
+					}
+
+					return checksum.Run(newConfig(c), os.Stdout, c.Args().First(), c.String("name"), c.String("extract-filepath"))
+				},
+			},
 			{
 				Name:  "install",
 				Usage: "install dependencies from lockal.star",
 				Flags: []cli.Flag{
+					cacheDirectoryFlag,
+					platformFlag,
+					prefixFlag,
+					&cli.IntFlag{
+						Name:  "jobs",
+						Usage: "number of dependencies to install at the same time",
+						Value: 1,
+					},
+					&cli.BoolFlag{
+						Name:  "locked",
+						Usage: "fail if lockal.star does not match lockal.lock",
+					},
+					&cli.BoolFlag{
+						Name:  "offline",
+						Usage: "install only from the cache without downloading anything",
+					},
+					&cli.BoolFlag{
+						Name:  "dry-run",
+						Usage: "print what would be installed without changing anything",
+					},
+					&cli.BoolFlag{
+						Name:  "prune",
+						Usage: "remove previously installed files whose rule no longer exists in lockal.star",
+					},
 					&cli.StringFlag{
-						Name:    "cache-directory",
-						Usage:   "where to save cached downloads",
-						Value:   filepath.Join(userHomeDir, ".cache"),
-						EnvVars: []string{"XDG_CACHE_DIR"},
+						Name:  "from-bundle",
+						Usage: "add files from a bundle created by lockal bundle create to the cache, then install without downloading anything",
+					},
+					&cli.StringFlag{
+						Name:    "remote-cache",
+						Usage:   "URL of a remote cache to check for files by checksum before downloading them from their location",
+						EnvVars: []string{"LOCKAL_REMOTE_CACHE"},
+					},
+					&cli.BoolFlag{
+						Name:    "remote-cache-upload",
+						Usage:   "upload files downloaded from their location to the remote cache",
+						EnvVars: []string{"LOCKAL_REMOTE_CACHE_UPLOAD"},
 					},
 				},
 				Action: func(c *cli.Context) error {
-					deps, err := parse.GetDependencies(afero.NewOsFs())
+					cfg := newConfig(c)
+
+					if c.Bool("dry-run") {
+						if c.IsSet("from-bundle") {
+							return fmt.Errorf("--dry-run can't be used with --from-bundle since importing a bundle modifies the cache")
+						}
+
+						// cache entries aren't locked either, since locking creates lock files in the cache
+						cfg.Fs = afero.NewReadOnlyFs(cfg.Fs)
+						cfg.LockFile = nil
+
+						deps, err := parseDependencies(cfg.Fs, c.String("platform"))
+						if err != nil {
+							return err
+						}
+
+						if deps, err = dependency.ResolveChecksums(cfg, deps); err != nil {
+							return fmt.Errorf("checksum files must be cached for --dry-run, run lockal install first: %w", err)
+						}
+
+						if err = checkLocked(c, deps); err != nil {
+							return err
+						}
+
+						cfg.Fs = platform.NewPrefixFs(cfg.Fs, getPrefix(c))
+
+						return install.DryRun(cfg, deps)
+					}
+
+					unlock, err := filelock.Lock(state.LockFilepath, logCtx)
 					if err != nil {
 						return err
 					}
+					defer unlock()
 
-					getFile := func(dest, src string) error {
-						return gogetter.GetFile(dest, src)
-					}
+					if c.IsSet("from-bundle") {
+						bundleFile, err := os.Open(c.String("from-bundle"))
+						if err != nil {
+							return err
+						}
+
+						err = bundle.Import(cfg, bundleFile)
+						bundleFile.Close()
 
-					extractFileFromArchive := func(archiveFileName, archivePath, extractFilepath, extractToDir string) error {
-						extractorType, err := archiver.ByExtension(archiveFileName)
 						if err != nil {
 							return err
 						}
+					}
 
-						extractor, ok := extractorType.(archiver.Extractor)
-						if !ok {
-							return fmt.Errorf("invalid extractor")
+					if c.Bool("offline") || c.IsSet("from-bundle") {
+						cfg.RemoteCache = ""
+						cfg.GetFile = func(dest, src string, hash io.Writer) error {
+							return fmt.Errorf("unable to download %s while offline", src)
 						}
+					}
 
-						return extractor.Extract(archivePath, extractFilepath, extractToDir)
+					// checksum files are read after importing a bundle and disabling downloads, so offline installs
+					// read them from the cache
+					deps, err := getDependencies(cfg, c.String("platform"))
+					if err != nil {
+						return err
 					}
 
-					cfg := config.Config{
-						CacheDir:               c.String("cache-directory"),
-						Fs:                     afero.NewOsFs(),
-						LogCtx:                 logCtx,
-						GetFile:                getFile,
-						ExtractFileFromArchive: extractFileFromArchive,
+					if err = checkLocked(c, deps); err != nil {
+						return err
 					}
 
-					for _, dep := range deps {
-						if err = dep.Download(cfg); err != nil {
+					// installed files and the state recording them are kept in the prefix, so installing for another
+					// platform doesn't replace or prune the executables for this one
+					if prefix := getPrefix(c); prefix != "" {
+						logCtx.Info(fmt.Sprintf("installing for %s into %s", c.String("platform"), prefix))
+
+						cfg.Fs = platform.NewPrefixFs(cfg.Fs, prefix)
+					}
+
+					if c.Bool("offline") || c.IsSet("from-bundle") {
+						if err = install.CheckOffline(cfg, deps); err != nil {
 							return err
 						}
 					}
 
+					st, err := state.Read(cfg.Fs)
+					if err != nil {
+						return err
+					}
+
+					installed, installErr := install.Run(cfg, deps, c.Int("jobs"))
+
+					if st, err = state.Record(st, installed); err != nil {
+						return err
+					}
+
+					if installErr == nil && c.Bool("prune") {
+						if st, err = state.Prune(cfg.Fs, logCtx, st, deps); err != nil {
+							return err
+						}
+					}
+
+					if err = state.Write(cfg.Fs, st); err != nil {
+						return err
+					}
+
+					return installErr
+				},
+			},
+			{
+				Name:  "lock",
+				Usage: "record the resolved dependencies from lockal.star for each platform in lockal.lock",
+				Flags: []cli.Flag{
+					cacheDirectoryFlag,
+					&cli.StringSliceFlag{
+						Name:  "platform",
+						Usage: "os/arch to lock, may be provided multiple times",
+						Value: cli.NewStringSlice(lock.DefaultPlatforms...),
+					},
+				},
+				Action: func(c *cli.Context) error {
+					cfg := newConfig(c)
+
+					lockFile, err := lock.Create(cfg, c.StringSlice("platform"))
+					if err != nil {
+						return err
+					}
+
+					if err = lock.Write(cfg.Fs, lockFile); err != nil {
+						return err
+					}
+
+					logCtx.Info(fmt.Sprintf("wrote %s", lock.Filename))
+
 					return nil
 				},
 			},
+			{
+				Name:  "prune",
+				Usage: "remove previously installed files whose rule no longer exists in lockal.star",
+				Flags: []cli.Flag{
+					platformFlag,
+					prefixFlag,
+				},
+				Action: func(c *cli.Context) error {
+					deps, err := parseDependencies(afero.NewOsFs(), c.String("platform"))
+					if err != nil {
+						return err
+					}
+
+					fs := platform.NewPrefixFs(afero.NewOsFs(), getPrefix(c))
+
+					unlock, err := filelock.Lock(state.LockFilepath, logCtx)
+					if err != nil {
+						return err
+					}
+					defer unlock()
+
+					st, err := state.Read(fs)
+					if err != nil {
+						return err
+					}
+
+					if st, err = state.Prune(fs, logCtx, st, deps); err != nil {
+						return err
+					}
+
+					return state.Write(fs, st)
+				},
+			},
+			{
+				Name:      "uninstall",
+				Usage:     "remove executables installed from lockal.star, unless they were modified after being installed",
+				ArgsUsage: "[NAME...]",
+				Flags: []cli.Flag{
+					cacheDirectoryFlag,
+					platformFlag,
+					prefixFlag,
+				},
+				Action: func(c *cli.Context) error {
+					cfg := newConfig(c)
+
+					deps, err := getDependencies(cfg, c.String("platform"))
+					if err != nil {
+						return err
+					}
+
+					fs := platform.NewPrefixFs(cfg.Fs, getPrefix(c))
+
+					unlock, err := filelock.Lock(state.LockFilepath, logCtx)
+					if err != nil {
+						return err
+					}
+					defer unlock()
+
+					st, err := state.Read(fs)
+					if err != nil {
+						return err
+					}
+
+					if st, err = state.Uninstall(fs, logCtx, st, deps, c.Args().Slice()); err != nil {
+						return err
+					}
+
+					return state.Write(fs, st)
+				},
+			},
+			{
+				Name:  "verify",
+				Usage: "verify dependencies from lockal.star are installed without modifying anything",
+				Flags: []cli.Flag{
+					cacheDirectoryFlag,
+					platformFlag,
+					prefixFlag,
+				},
+				Action: func(c *cli.Context) error {
+					cfg := config.Config{
+						CacheDir: c.String("cache-directory"),
+						Project:  workingDir,
+						Fs:       afero.NewReadOnlyFs(afero.NewOsFs()),
+						LogCtx:   logCtx,
+						GetFile: func(dest, src string, hash io.Writer) error {
+							return fmt.Errorf("unable to download %s while verifying", src)
+						},
+					}
+
+					deps, err := parseDependencies(cfg.Fs, c.String("platform"))
+					if err != nil {
+						return err
+					}
+
+					if deps, err = dependency.ResolveChecksums(cfg, deps); err != nil {
+						return fmt.Errorf("checksum files must be cached to verify, run lockal install first: %w", err)
+					}
+
+					cfg.Fs = platform.NewPrefixFs(cfg.Fs, getPrefix(c))
+
+					return verify.Run(cfg, deps)
+				},
+			},
 			{
 				Name:  "version",
 				Usage: "print version of lockal",
@@ -123,3 +592,230 @@ func main() {
 		logCtx.Fatal(err.Error())
 	}
 }
+
+// getDependencies evaluates lockal.star for platform and reads checksums from any checksum files, which are downloaded
+// to the cache like any other file
+func getDependencies(cfg config.Config, platform string) ([]dependency.Dependency, error) {
+	deps, err := parseDependencies(cfg.Fs, platform)
+	if err != nil {
+		return nil, err
+	}
+
+	return dependency.ResolveChecksums(cfg, deps)
+}
+
+// parseDependencies evaluates lockal.star for platform without reading checksums from checksum files
+func parseDependencies(fs afero.Fs, platform string) ([]dependency.Dependency, error) {
+	operatingSystem, architecture, err := lock.ParsePlatform(platform)
+	if err != nil {
+		return nil, err
+	}
+
+	return parse.GetDependenciesForPlatform(fs, operatingSystem, architecture)
+}
+
+// checkLocked returns an error if --locked is set and deps no longer match lockal.lock for --platform
+func checkLocked(c *cli.Context, deps []dependency.Dependency) error {
+	if !c.Bool("locked") {
+		return nil
+	}
+
+	lockFile, err := lock.Read(afero.NewOsFs())
+	if err != nil {
+		return err
+	}
+
+	return lock.Check(lockFile, c.String("platform"), deps)
+}
+
+// getPrefix returns the directory to install dependencies for --platform into, which is the working directory for the
+// current platform
+func getPrefix(c *cli.Context) string {
+	if c.IsSet("prefix") {
+		return c.String("prefix")
+	}
+
+	return platform.GetDefaultPrefix(c.String("platform"))
+}
+
+// newGetFile returns a getFile that authenticates with the credentials for each location's host
+func newGetFile(globalConfig globalconfig.Config) func(dest, src string, hash io.Writer) error {
+	return func(dest, src string, hash io.Writer) error {
+		var header http.Header
+		if credential, ok := globalConfig.GetCredential(src, os.Getenv); ok {
+			header = credential.GetHeader()
+		}
+
+		return gogetter.GetFile(dest, src, gogetter.WithProgress(hashingProgressTracker{hash}), withGetters(header))
+	}
+}
+
+// withGetters gives each download its own getters, since go-getter's default getters are shared by every client and
+// each client replaces their progress tracker, which would write one download's bytes to another download's hash.
+// Local files are copied instead of symlinked, so lockal never changes or caches a link to the user's own file.
+// header is sent with HTTP requests to the location's host, ~/.netrc is still used when header doesn't include
+// Authorization.
+func withGetters(header http.Header) gogetter.ClientOption {
+	return func(client *gogetter.Client) error {
+		httpGetter := &gogetter.HttpGetter{
+			Netrc:  true,
+			Header: header,
+		}
+
+		if len(header) > 0 {
+			httpGetter.Client = &http.Client{CheckRedirect: withoutHeaderAcrossHosts(header)}
+		}
+
+		client.Getters = map[string]gogetter.Getter{
+			"file":  &gogetter.FileGetter{Copy: true},
+			"git":   new(gogetter.GitGetter),
+			"gcs":   new(gogetter.GCSGetter),
+			"hg":    new(gogetter.HgGetter),
+			"s3":    new(gogetter.S3Getter),
+			"http":  httpGetter,
+			"https": httpGetter,
+		}
+
+		return nil
+	}
+}
+
+// newPutFile returns a putFile that authenticates with the credentials for dest's host
+func newPutFile(globalConfig globalconfig.Config) func(dest string, content io.Reader, size int64) error {
+	return func(dest string, content io.Reader, size int64) error {
+		request, err := http.NewRequest(http.MethodPut, dest, content)
+		if err != nil {
+			return err
+		}
+
+		request.ContentLength = size
+
+		header := http.Header{}
+		if credential, ok := globalConfig.GetCredential(dest, os.Getenv); ok {
+			header = credential.GetHeader()
+		}
+
+		for name, values := range header {
+			request.Header[name] = values
+		}
+
+		client := &http.Client{CheckRedirect: withoutHeaderAcrossHosts(header)}
+
+		response, err := client.Do(request)
+		if err != nil {
+			return err
+		}
+		defer response.Body.Close()
+
+		if response.StatusCode < 200 || response.StatusCode > 299 {
+			return fmt.Errorf("bad response code: %d", response.StatusCode)
+		}
+
+		return nil
+	}
+}
+
+// withoutHeaderAcrossHosts returns a redirect policy that removes header from redirects to another host. Go only does
+// this for Authorization and Cookie, so credentials sent in headers such as PRIVATE-TOKEN would otherwise be sent to
+// wherever a release asset is redirected to, such as a CDN.
+func withoutHeaderAcrossHosts(header http.Header) func(request *http.Request, via []*http.Request) error {
+	names := []string{}
+	for name := range header {
+		names = append(names, name)
+	}
+
+	return func(request *http.Request, via []*http.Request) error {
+		// the same limit as http.Client's default redirect policy
+		if len(via) >= 10 {
+			return fmt.Errorf("stopped after 10 redirects")
+		}
+
+		if !strings.EqualFold(request.URL.Host, via[0].URL.Host) {
+			for _, name := range names {
+				request.Header.Del(name)
+			}
+		}
+
+		return nil
+	}
+}
+
+// addSecrets keeps every credential provided by the global config or environment variables out of logs
+func addSecrets(globalConfig globalconfig.Config) {
+	for _, credential := range globalConfig.Credentials {
+		for _, secret := range credential.GetSecrets() {
+			redact.AddSecret(secret)
+		}
+	}
+
+	for _, envVar := range os.Environ() {
+		parts := strings.SplitN(envVar, "=", 2)
+
+		if strings.HasPrefix(parts[0], "LOCKAL_TOKEN_") || strings.HasPrefix(parts[0], "LOCKAL_PASSWORD_") {
+			redact.AddSecret(parts[1])
+		}
+	}
+}
+
+func extractFileFromArchive(archiveFileName, archivePath, extractFilepath, extractToDir string) error {
+	extractorType, err := archiver.ByExtension(archiveFileName)
+	if err != nil {
+		return err
+	}
+
+	extractor, ok := extractorType.(archiver.Extractor)
+	if !ok {
+		return fmt.Errorf("invalid extractor")
+	}
+
+	return extractor.Extract(archivePath, extractFilepath, extractToDir)
+}
+
+func listFilesInArchive(archiveFileName, archivePath string) ([]string, error) {
+	walkerType, err := archiver.ByExtension(archiveFileName)
+	if err != nil {
+		return nil, config.ErrNotArchive
+	}
+
+	walker, ok := walkerType.(archiver.Walker)
+	if !ok {
+		return nil, fmt.Errorf("invalid walker")
+	}
+
+	files := []string{}
+
+	err = walker.Walk(archivePath, func(file archiver.File) error {
+		if file.IsDir() {
+			return nil
+		}
+
+		switch header := file.Header.(type) {
+		case *tar.Header:
+			files = append(files, header.Name)
+		case zip.FileHeader:
+			files = append(files, header.Name)
+		default:
+			files = append(files, file.Name())
+		}
+
+		return nil
+	})
+
+	return files, err
+}
+
+// hashingProgressTracker writes downloaded bytes to hash as they are received, so downloads don't need to be re-read to
+// compute their checksum
+type hashingProgressTracker struct {
+	hash io.Writer
+}
+
+func (tracker hashingProgressTracker) TrackProgress(src string, currentSize, totalSize int64, stream io.ReadCloser) io.ReadCloser {
+	return struct {
+		io.Reader
+		io.Closer
+	}{
+		Reader: io.TeeReader(stream, tracker.hash),
+		Closer: stream,
+	}
+}
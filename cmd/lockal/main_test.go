@@ -0,0 +1,147 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha512"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/dustinspecker/lockal/internal/globalconfig"
+)
+
+func TestGetFileHashesConcurrentDownloadsSeparately(t *testing.T) {
+	files := map[string][]byte{}
+	for i := 0; i < 8; i++ {
+		files[fmt.Sprintf("/file-%d", i)] = bytes.Repeat([]byte{byte('a' + i)}, 1024*1024)
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		content, ok := files[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+
+			return
+		}
+
+		w.Write(content)
+	}))
+	defer server.Close()
+
+	getFile := newGetFile(globalconfig.Config{})
+	dir := t.TempDir()
+
+	for round := 0; round < 5; round++ {
+		var wg sync.WaitGroup
+
+		for path, content := range files {
+			wg.Add(1)
+
+			go func(path string, content []byte) {
+				defer wg.Done()
+
+				hash := sha512.New()
+				if err := getFile(filepath.Join(dir, fmt.Sprintf("%d%s", round, path)), server.URL+path, hash); err != nil {
+					t.Errorf("unexpected error downloading %s: %v", path, err)
+
+					return
+				}
+
+				if expected := sha512.Sum512(content); !bytes.Equal(hash.Sum(nil), expected[:]) {
+					t.Errorf("expected hash of %s to only include its own bytes", path)
+				}
+			}(path, content)
+		}
+
+		wg.Wait()
+	}
+}
+
+func TestGetFileOnlySendsCredentialsToTheirHost(t *testing.T) {
+	var assetHeader http.Header
+
+	assetServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assetHeader = r.Header
+
+		w.Write([]byte("an asset"))
+	}))
+	defer assetServer.Close()
+
+	var releaseHeader http.Header
+
+	releaseServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		releaseHeader = r.Header
+
+		http.Redirect(w, r, assetServer.URL+"/asset", http.StatusFound)
+	}))
+	defer releaseServer.Close()
+
+	releaseURL, err := url.Parse(releaseServer.URL)
+	if err != nil {
+		t.Fatalf("unexpected error parsing %s: %v", releaseServer.URL, err)
+	}
+
+	globalConfig := globalconfig.Config{
+		Credentials: []globalconfig.Credential{
+			{Host: releaseURL.Host, Token: "secret", Header: "PRIVATE-TOKEN"},
+		},
+	}
+
+	if err = newGetFile(globalConfig)(filepath.Join(t.TempDir(), "asset"), releaseServer.URL+"/release", sha512.New()); err != nil {
+		t.Fatalf("unexpected error downloading: %v", err)
+	}
+
+	if releaseHeader.Get("PRIVATE-TOKEN") != "secret" {
+		t.Errorf("expected credential to be sent to its host, but got %v", releaseHeader)
+	}
+
+	if assetHeader == nil {
+		t.Fatal("expected download to be redirected to the asset server")
+	}
+
+	if value := assetHeader.Get("PRIVATE-TOKEN"); value != "" {
+		t.Errorf("expected credential to not be sent to another host after a redirect, but got %s", value)
+	}
+}
+
+func TestGetFileCopiesLocalFiles(t *testing.T) {
+	dir := t.TempDir()
+
+	source := filepath.Join(dir, "tool.sh")
+	if err := ioutil.WriteFile(source, []byte("a local tool"), 0755); err != nil {
+		t.Fatalf("unexpected error writing %s: %v", source, err)
+	}
+
+	dest := filepath.Join(dir, "tool")
+	if err := newGetFile(globalconfig.Config{})(dest, source, sha512.New()); err != nil {
+		t.Fatalf("unexpected error getting %s: %v", source, err)
+	}
+
+	stat, err := os.Lstat(dest)
+	if err != nil {
+		t.Fatalf("unexpected error stating %s: %v", dest, err)
+	}
+
+	if stat.Mode()&os.ModeSymlink != 0 {
+		t.Errorf("expected %s to be a copy of %s instead of a symbolic link", dest, source)
+	}
+
+	if err = os.Chmod(dest, 0644); err != nil {
+		t.Fatalf("unexpected error changing mode of %s: %v", dest, err)
+	}
+
+	stat, err = os.Stat(source)
+	if err != nil {
+		t.Fatalf("unexpected error stating %s: %v", source, err)
+	}
+
+	if stat.Mode() != 0755 {
+		t.Errorf("expected %s to still be marked 0755, but was %v", source, stat.Mode())
+	}
+}
@@ -15,4 +15,5 @@ require (
 	github.com/urfave/cli/v2 v2.3.0
 	github.com/xi2/xz v0.0.0-20171230120015-48954b6210f8 // indirect
 	go.starlark.net v0.0.0-20201210151846-e81fc95f7bd5
+	golang.org/x/crypto v0.0.0-20190820162420-60c769a6c586
 )
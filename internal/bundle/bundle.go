@@ -0,0 +1,150 @@
+package bundle
+
+import (
+	"archive/tar"
+	"fmt"
+	"io"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+
+	"github.com/dustinspecker/lockal/internal/config"
+	"github.com/dustinspecker/lockal/internal/dependency"
+	"github.com/dustinspecker/lockal/internal/digest"
+	"github.com/dustinspecker/lockal/internal/lock"
+	"github.com/dustinspecker/lockal/internal/parse"
+)
+
+var entryPattern = regexp.MustCompile(`^([a-z0-9]+)/([0-9a-f]+)(\.sig)?$`)
+
+// Create evaluates lockal.star for each platform and writes every file needed to install the dependencies to out as
+// a tar, keyed by checksum in the same ALGORITHM/CHECKSUM layout as remote caches
+func Create(cfg config.Config, out io.Writer, platforms []string) error {
+	cachePaths := map[string]bool{}
+
+	for _, platform := range platforms {
+		operatingSystem, architecture, err := lock.ParsePlatform(platform)
+		if err != nil {
+			return err
+		}
+
+		deps, err := parse.GetDependenciesForPlatform(cfg.Fs, operatingSystem, architecture)
+		if err != nil {
+			return fmt.Errorf("evaluating lockal.star for %s: %w", platform, err)
+		}
+
+		if deps, err = dependency.ResolveChecksums(cfg, deps); err != nil {
+			return fmt.Errorf("resolving checksums for %s: %w", platform, err)
+		}
+
+		for _, dep := range deps {
+			depCfg := cfg
+			depCfg.LogCtx = cfg.LogCtx.WithField("dependency", dep.GetName())
+
+			depCachePaths, err := dep.Cache(depCfg)
+			if err != nil {
+				return fmt.Errorf("%s: %w", dep.GetName(), err)
+			}
+
+			for _, cachePath := range depCachePaths {
+				cachePaths[cachePath] = true
+			}
+		}
+	}
+
+	sortedCachePaths := []string{}
+	for cachePath := range cachePaths {
+		sortedCachePaths = append(sortedCachePaths, cachePath)
+	}
+
+	sort.Strings(sortedCachePaths)
+
+	tarWriter := tar.NewWriter(out)
+
+	for _, cachePath := range sortedCachePaths {
+		if err := addFile(cfg, tarWriter, cachePath); err != nil {
+			return err
+		}
+	}
+
+	if err := tarWriter.Close(); err != nil {
+		return err
+	}
+
+	cfg.LogCtx.Info(fmt.Sprintf("bundled %d files for %d platforms", len(sortedCachePaths), len(platforms)))
+
+	return nil
+}
+
+// Import adds every file in the bundle read from in to the cache, verifying each file against its checksum
+func Import(cfg config.Config, in io.Reader) error {
+	tarReader := tar.NewReader(in)
+	imported := 0
+
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("reading bundle: %w", err)
+		}
+
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		match := entryPattern.FindStringSubmatch(header.Name)
+		if match == nil {
+			return fmt.Errorf("unexpected file %s in bundle, expected ALGORITHM/CHECKSUM", header.Name)
+		}
+
+		if match[3] != "" {
+			err = dependency.ImportSignatureToCache(cfg, digest.Format(match[1], match[2]), tarReader)
+		} else {
+			err = dependency.ImportToCache(cfg, digest.Format(match[1], match[2]), tarReader)
+		}
+
+		if err != nil {
+			return err
+		}
+
+		imported++
+	}
+
+	cfg.LogCtx.Info(fmt.Sprintf("imported %d files from bundle to cache", imported))
+
+	return nil
+}
+
+func addFile(cfg config.Config, tarWriter *tar.Writer, cachePath string) error {
+	file, err := cfg.Fs.Open(cachePath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	stat, err := file.Stat()
+	if err != nil {
+		return err
+	}
+
+	// cache entries are stored at ALGORITHM/PREFIX/CHECKSUM within the cache
+	algorithm := filepath.Base(filepath.Dir(filepath.Dir(cachePath)))
+
+	header := &tar.Header{
+		Name:    path.Join(algorithm, filepath.Base(cachePath)),
+		Mode:    0644,
+		Size:    stat.Size(),
+		ModTime: stat.ModTime(),
+	}
+
+	if err = tarWriter.WriteHeader(header); err != nil {
+		return err
+	}
+
+	_, err = io.Copy(tarWriter, file)
+
+	return err
+}
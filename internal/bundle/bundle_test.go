@@ -0,0 +1,114 @@
+package bundle
+
+import (
+	"archive/tar"
+	"bytes"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/apex/log"
+	"github.com/apex/log/handlers/memory"
+	"github.com/spf13/afero"
+
+	"github.com/dustinspecker/lockal/internal/config"
+)
+
+const (
+	fileAChecksum = "a705aaf587ddc9ed135d4c318c339f3a0d6eb3a2e11936942afbfcd65254da6a1600b7b8e27f59464219fdc704f3b96c9953d80c05632411f475eea6f4548963"
+	fileBChecksum = "b705aaf587ddc9ed135d4c318c339f3a0d6eb3a2e11936942afbfcd65254da6a1600b7b8e27f59464219fdc704f3b96c9953d80c05632411f475eea6f4548963"
+)
+
+func getConfig(fs afero.Fs, cacheDir string) config.Config {
+	log.SetLevel(log.DebugLevel)
+	log.SetHandler(memory.New())
+
+	return config.Config{
+		CacheDir: cacheDir,
+		Fs:       fs,
+		LogCtx: log.WithFields(log.Fields{
+			"app": "lockal-test",
+		}),
+	}
+}
+
+func TestCreateAndImport(t *testing.T) {
+	fs := afero.NewMemMapFs()
+
+	lockalStar := fmt.Sprintf(`
+executable(
+  name = "bin/a",
+  location = "some.sh/a-%%s" %% LOCKAL_OS,
+  checksum = "%s",
+)
+`, fileAChecksum)
+
+	if err := afero.WriteFile(fs, "lockal.star", []byte(lockalStar), 0644); err != nil {
+		t.Fatalf("unexpected error creating lockal.star: %v", err)
+	}
+
+	downloaded := []string{}
+
+	cfg := getConfig(fs, "/cache")
+	cfg.GetFile = func(dest, src string, hash io.Writer) error {
+		downloaded = append(downloaded, src)
+
+		return afero.WriteFile(fs, dest, []byte("file a"), 0644)
+	}
+
+	out := &bytes.Buffer{}
+	if err := Create(cfg, out, []string{"linux/amd64", "darwin/amd64"}); err != nil {
+		t.Fatalf("unexpected error when invoking Create: %v", err)
+	}
+
+	// both platforms resolve to the same checksum, so it only needs to be downloaded once
+	if len(downloaded) != 1 {
+		t.Errorf("expected a single download, but got %v", downloaded)
+	}
+
+	if _, err := fs.Stat("bin/a"); err == nil {
+		t.Error("expected Create to not install bin/a")
+	}
+
+	importCfg := getConfig(fs, "/other-cache")
+	if err := Import(importCfg, bytes.NewReader(out.Bytes())); err != nil {
+		t.Fatalf("unexpected error when invoking Import: %v", err)
+	}
+
+	content, err := afero.ReadFile(fs, "/other-cache/lockal/sha512/a7/"+fileAChecksum)
+	if err != nil {
+		t.Fatalf("unexpected error reading imported file: %v", err)
+	}
+
+	if string(content) != "file a" {
+		t.Errorf("expected imported file to contain file a, but got %s", string(content))
+	}
+}
+
+func TestImportRejectsFileNotMatchingChecksum(t *testing.T) {
+	bundle := &bytes.Buffer{}
+	tarWriter := tar.NewWriter(bundle)
+
+	content := []byte("not file b")
+	if err := tarWriter.WriteHeader(&tar.Header{Name: "sha512/" + fileBChecksum, Mode: 0644, Size: int64(len(content))}); err != nil {
+		t.Fatalf("unexpected error writing header: %v", err)
+	}
+
+	if _, err := tarWriter.Write(content); err != nil {
+		t.Fatalf("unexpected error writing content: %v", err)
+	}
+
+	if err := tarWriter.Close(); err != nil {
+		t.Fatalf("unexpected error closing tar: %v", err)
+	}
+
+	err := Import(getConfig(afero.NewMemMapFs(), "/cache"), bundle)
+	if err == nil {
+		t.Fatal("expected an error when a bundled file doesn't match its checksum")
+	}
+
+	expectedMessage := fmt.Sprintf("imported %s did not match expected checksum", fileBChecksum)
+	if err.Error() != expectedMessage {
+		t.Errorf("expected error message of %s, but got %s", expectedMessage, err.Error())
+	}
+}
@@ -0,0 +1,372 @@
+package cache
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/apex/log"
+	"github.com/spf13/afero"
+
+	"github.com/dustinspecker/lockal/internal/dependency"
+	"github.com/dustinspecker/lockal/internal/digest"
+	"github.com/dustinspecker/lockal/internal/lock"
+)
+
+// Entry is a single file in the cache
+type Entry struct {
+	Path     string
+	Checksum string
+	Size     int64
+	LastUsed time.Time
+	Project  string
+	Rule     string
+}
+
+// List returns every entry in the cache, least recently used first
+func List(fs afero.Fs, cacheDir string) ([]Entry, error) {
+	entries := []Entry{}
+
+	for _, algorithm := range digest.Algorithms() {
+		algorithmEntries, err := listAlgorithm(fs, cacheDir, algorithm)
+		if err != nil {
+			return entries, err
+		}
+
+		entries = append(entries, algorithmEntries...)
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].LastUsed.Before(entries[j].LastUsed)
+	})
+
+	return entries, nil
+}
+
+// listAlgorithm returns every entry in the cache keyed by algorithm
+func listAlgorithm(fs afero.Fs, cacheDir, algorithm string) ([]Entry, error) {
+	entries := []Entry{}
+	algorithmDir := filepath.Join(getCacheRoot(cacheDir), algorithm)
+
+	prefixDirs, err := afero.ReadDir(fs, algorithmDir)
+	if os.IsNotExist(err) {
+		return entries, nil
+	}
+	if err != nil {
+		return entries, err
+	}
+
+	for _, prefixDir := range prefixDirs {
+		if !prefixDir.IsDir() {
+			continue
+		}
+
+		files, err := afero.ReadDir(fs, filepath.Join(algorithmDir, prefixDir.Name()))
+		if err != nil {
+			return entries, err
+		}
+
+		for _, file := range files {
+			if file.IsDir() || !isCacheEntry(file.Name()) {
+				continue
+			}
+
+			checksum, err := digest.Normalize(digest.Format(algorithm, file.Name()))
+			if err != nil {
+				continue
+			}
+
+			path := filepath.Join(algorithmDir, prefixDir.Name(), file.Name())
+
+			entry := Entry{
+				Path:     path,
+				Checksum: checksum,
+				Size:     file.Size(),
+				LastUsed: file.ModTime(),
+			}
+
+			cacheUse, ok, err := dependency.ReadCacheUse(fs, path)
+			if err != nil {
+				return entries, err
+			}
+
+			if ok {
+				entry.LastUsed = cacheUse.LastUsed
+				entry.Project = cacheUse.Project
+				entry.Rule = cacheUse.Rule
+			}
+
+			entries = append(entries, entry)
+		}
+	}
+
+	return entries, nil
+}
+
+// Print writes a table of entries to out followed by their total size
+func Print(out io.Writer, entries []Entry) error {
+	writer := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintln(writer, "CHECKSUM\tSIZE\tLAST USED\tPROJECT\tRULE")
+
+	var totalSize int64
+
+	for _, entry := range entries {
+		totalSize += entry.Size
+
+		fmt.Fprintf(writer, "%s\t%s\t%s\t%s\t%s\n", shortChecksum(entry.Checksum), FormatSize(entry.Size), entry.LastUsed.Local().Format(time.RFC3339), valueOrDash(entry.Project), valueOrDash(entry.Rule))
+	}
+
+	if err := writer.Flush(); err != nil {
+		return err
+	}
+
+	_, err := fmt.Fprintf(out, "%d entries, %s total\n", len(entries), FormatSize(totalSize))
+
+	return err
+}
+
+// Verify re-hashes every entry in the cache and removes the entries that don't match their checksum. lockFile is used to
+// wait for other lockal processes using an entry before removing it.
+func Verify(fs afero.Fs, logCtx *log.Entry, cacheDir string, lockFile func(path string, logCtx *log.Entry) (func(), error)) error {
+	entries, err := List(fs, cacheDir)
+	if err != nil {
+		return err
+	}
+
+	removed := 0
+
+	for _, entry := range entries {
+		status, err := dependency.VerifyFile(fs, entry.Path, entry.Checksum)
+		if err != nil {
+			return err
+		}
+
+		if status == dependency.StatusOK {
+			continue
+		}
+
+		logCtx.Warn(fmt.Sprintf("removing %s since it doesn't match its checksum", entry.Path))
+
+		if err = remove(fs, logCtx, lockFile, entry); err != nil {
+			return err
+		}
+
+		removed++
+	}
+
+	logCtx.Info(fmt.Sprintf("verified %d cache entries, removed %d", len(entries), removed))
+
+	return nil
+}
+
+// GCPolicy decides which cache entries GC removes. Entries referenced by the current lockal.star are always kept.
+type GCPolicy struct {
+	// MaxSize removes the least recently used entries until the cache is at most MaxSize bytes, nil means no limit
+	MaxSize *int64
+	// OlderThan removes entries that haven't been used for OlderThan, nil means no limit
+	OlderThan *time.Duration
+}
+
+// GC removes cache entries according to policy, keeping entries referenced by deps. lockFile is used to wait for other
+// lockal processes using an entry before removing it.
+func GC(fs afero.Fs, logCtx *log.Entry, cacheDir string, lockFile func(path string, logCtx *log.Entry) (func(), error), deps []dependency.Dependency, policy GCPolicy, now time.Time) error {
+	referenced, err := getReferencedChecksums(deps)
+	if err != nil {
+		return err
+	}
+
+	entries, err := List(fs, cacheDir)
+	if err != nil {
+		return err
+	}
+
+	var totalSize int64
+	for _, entry := range entries {
+		totalSize += entry.Size
+	}
+
+	removed := 0
+	var removedSize int64
+
+	// entries are sorted least recently used first, so the oldest entries are removed first to satisfy MaxSize
+	for _, entry := range entries {
+		if referenced[entry.Checksum] {
+			continue
+		}
+
+		tooOld := policy.OlderThan != nil && now.Sub(entry.LastUsed) > *policy.OlderThan
+		tooBig := policy.MaxSize != nil && totalSize-removedSize > *policy.MaxSize
+
+		if !tooOld && !tooBig {
+			continue
+		}
+
+		logCtx.Info(fmt.Sprintf("removing %s last used %s", entry.Path, entry.LastUsed.Format(time.RFC3339)))
+
+		if err = remove(fs, logCtx, lockFile, entry); err != nil {
+			return err
+		}
+
+		removed++
+		removedSize += entry.Size
+	}
+
+	if policy.MaxSize != nil && totalSize-removedSize > *policy.MaxSize {
+		logCtx.Warn(fmt.Sprintf("cache is still %s since entries referenced by lockal.star are kept", FormatSize(totalSize-removedSize)))
+	}
+
+	logCtx.Info(fmt.Sprintf("removed %d cache entries, freeing %s", removed, FormatSize(removedSize)))
+
+	return nil
+}
+
+// ParseSize parses sizes such as 500MB or 10GB, units are powers of 1024
+func ParseSize(size string) (int64, error) {
+	units := []struct {
+		suffix     string
+		multiplier int64
+	}{
+		{"TB", 1 << 40},
+		{"GB", 1 << 30},
+		{"MB", 1 << 20},
+		{"KB", 1 << 10},
+		{"B", 1},
+	}
+
+	value := strings.ToUpper(strings.TrimSpace(size))
+	multiplier := int64(1)
+
+	for _, unit := range units {
+		if strings.HasSuffix(value, unit.suffix) {
+			value = strings.TrimSuffix(value, unit.suffix)
+			multiplier = unit.multiplier
+
+			break
+		}
+	}
+
+	number, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+	if err != nil || number < 0 {
+		return 0, fmt.Errorf("invalid size %s, expected a size such as 500MB or 10GB", size)
+	}
+
+	return int64(number * float64(multiplier)), nil
+}
+
+// ParseAge parses durations such as 30d or 12h
+func ParseAge(age string) (time.Duration, error) {
+	if strings.HasSuffix(age, "d") {
+		days, err := strconv.Atoi(strings.TrimSuffix(age, "d"))
+		if err == nil && days >= 0 {
+			return time.Duration(days) * 24 * time.Hour, nil
+		}
+	}
+
+	duration, err := time.ParseDuration(age)
+	if err != nil || duration < 0 {
+		return 0, fmt.Errorf("invalid age %s, expected an age such as 30d or 12h", age)
+	}
+
+	return duration, nil
+}
+
+// FormatSize formats size in bytes for people to read
+func FormatSize(size int64) string {
+	units := []string{"B", "KB", "MB", "GB", "TB"}
+
+	value := float64(size)
+	unit := 0
+
+	for value >= 1024 && unit < len(units)-1 {
+		value /= 1024
+		unit++
+	}
+
+	if unit == 0 {
+		return fmt.Sprintf("%d%s", size, units[unit])
+	}
+
+	return fmt.Sprintf("%.1f%s", value, units[unit])
+}
+
+// shortChecksum shortens checksum's value while keeping its algorithm prefix, if any
+func shortChecksum(checksum string) string {
+	prefix := ""
+	if index := strings.Index(checksum, ":"); index != -1 {
+		prefix, checksum = checksum[:index+1], checksum[index+1:]
+	}
+
+	if len(checksum) > 12 {
+		checksum = checksum[:12]
+	}
+
+	return prefix + checksum
+}
+
+func valueOrDash(value string) string {
+	if value == "" {
+		return "-"
+	}
+
+	return value
+}
+
+func getCacheRoot(cacheDir string) string {
+	return filepath.Join(cacheDir, "lockal")
+}
+
+// isCacheEntry returns false for files lockal keeps next to cache entries, such as recorded uses, locks, signatures,
+// and partial downloads
+func isCacheEntry(name string) bool {
+	return !strings.HasPrefix(name, ".") && !strings.HasSuffix(name, ".json") && !strings.HasSuffix(name, ".lock") && !strings.HasSuffix(name, ".sig")
+}
+
+// remove deletes a cache entry and the files lockal keeps next to it, while holding the entry's lock so an install isn't
+// copying it at the same time. The lock file is kept, since removing it would let a process waiting on the removed lock
+// file and a process creating a new one both believe they hold the entry's lock.
+func remove(fs afero.Fs, logCtx *log.Entry, lockFile func(path string, logCtx *log.Entry) (func(), error), entry Entry) error {
+	unlock, err := dependency.LockCachePath(logCtx, entry.Path, lockFile)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if err = fs.Remove(entry.Path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	for _, sidecar := range []string{dependency.GetCacheUsePath(entry.Path), dependency.GetSignaturePath(entry.Path)} {
+		if err := fs.Remove(sidecar); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func getReferencedChecksums(deps []dependency.Dependency) (map[string]bool, error) {
+	entries, err := lock.GetEntries(deps)
+	if err != nil {
+		return nil, err
+	}
+
+	referenced := map[string]bool{}
+
+	for _, entry := range entries {
+		for _, checksum := range []string{entry.Checksum, entry.ArchiveChecksum, entry.ExecutableChecksum, entry.ChecksumURLChecksum} {
+			// entries are listed with normalized checksums, so sha512:VALUE and VALUE both reference the same entry
+			if normalized, err := digest.Normalize(checksum); err == nil {
+				referenced[normalized] = true
+			}
+		}
+	}
+
+	return referenced, nil
+}
@@ -0,0 +1,330 @@
+package cache
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/apex/log"
+	"github.com/apex/log/handlers/memory"
+	"github.com/spf13/afero"
+
+	"github.com/dustinspecker/lockal/internal/dependency"
+)
+
+const (
+	fileAChecksum = "a705aaf587ddc9ed135d4c318c339f3a0d6eb3a2e11936942afbfcd65254da6a1600b7b8e27f59464219fdc704f3b96c9953d80c05632411f475eea6f4548963"
+	fileBChecksum = "b705aaf587ddc9ed135d4c318c339f3a0d6eb3a2e11936942afbfcd65254da6a1600b7b8e27f59464219fdc704f3b96c9953d80c05632411f475eea6f4548963"
+	fileCChecksum = "c705aaf587ddc9ed135d4c318c339f3a0d6eb3a2e11936942afbfcd65254da6a1600b7b8e27f59464219fdc704f3b96c9953d80c05632411f475eea6f4548963"
+)
+
+func getLogCtx() *log.Entry {
+	log.SetLevel(log.DebugLevel)
+	log.SetHandler(memory.New())
+
+	return log.WithFields(log.Fields{
+		"app": "lockal-test",
+	})
+}
+
+func getPath(checksum string) string {
+	return "/cache/lockal/sha512/" + checksum[:2] + "/" + checksum
+}
+
+func writeEntry(t *testing.T, fs afero.Fs, checksum, content string, lastUsed time.Time) {
+	t.Helper()
+
+	if err := afero.WriteFile(fs, getPath(checksum), []byte(content), 0644); err != nil {
+		t.Fatalf("unexpected error creating cache entry: %v", err)
+	}
+
+	cacheUse := `{"last_used":"` + lastUsed.Format(time.RFC3339) + `","project":"/project","rule":"bin/` + checksum[:1] + `"}`
+	if err := afero.WriteFile(fs, dependency.GetCacheUsePath(getPath(checksum)), []byte(cacheUse), 0644); err != nil {
+		t.Fatalf("unexpected error creating cache use: %v", err)
+	}
+}
+
+func TestList(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	now := time.Date(2021, 1, 10, 0, 0, 0, 0, time.UTC)
+
+	writeEntry(t, fs, fileBChecksum, "file b", now)
+	writeEntry(t, fs, fileAChecksum, "file a", now.Add(-time.Hour))
+
+	if err := afero.WriteFile(fs, "/cache/lockal/sha512/a7/.lockal-tmp-123", []byte("partial"), 0644); err != nil {
+		t.Fatalf("unexpected error creating temp file: %v", err)
+	}
+
+	entries, err := List(fs, "/cache")
+	if err != nil {
+		t.Fatalf("unexpected error when invoking List: %v", err)
+	}
+
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 entries, but got %+v", entries)
+	}
+
+	if entries[0].Checksum != fileAChecksum {
+		t.Errorf("expected least recently used entry first, but got %s", entries[0].Checksum)
+	}
+
+	if entries[0].Size != 6 || entries[0].Project != "/project" || entries[0].Rule != "bin/a" {
+		t.Errorf("unexpected entry: %+v", entries[0])
+	}
+
+	out := &bytes.Buffer{}
+	if err = Print(out, entries); err != nil {
+		t.Fatalf("unexpected error when invoking Print: %v", err)
+	}
+
+	if !strings.Contains(out.String(), "2 entries, 12B total") {
+		t.Errorf("expected total size to be printed, but got:\n%s", out.String())
+	}
+}
+
+func TestListIncludesEntriesOfEveryAlgorithm(t *testing.T) {
+	fs := afero.NewMemMapFs()
+
+	writeEntry(t, fs, fileAChecksum, "file a", time.Now())
+
+	sha256Path := "/cache/lockal/sha256/63/63c5cdfc617fb8fe93888e68674717590957a25b5e08219b1a61c3f031f88ee6"
+	if err := afero.WriteFile(fs, sha256Path, []byte("file a"), 0644); err != nil {
+		t.Fatalf("unexpected error creating cache entry: %v", err)
+	}
+
+	entries, err := List(fs, "/cache")
+	if err != nil {
+		t.Fatalf("unexpected error when invoking List: %v", err)
+	}
+
+	checksums := map[string]string{}
+	for _, entry := range entries {
+		checksums[entry.Checksum] = entry.Path
+	}
+
+	if checksums["sha256:63c5cdfc617fb8fe93888e68674717590957a25b5e08219b1a61c3f031f88ee6"] != sha256Path {
+		t.Errorf("expected sha256 entry to be listed with a prefixed checksum, but got %+v", entries)
+	}
+
+	if checksums[fileAChecksum] != getPath(fileAChecksum) {
+		t.Errorf("expected sha512 entry to be listed without a prefix, but got %+v", entries)
+	}
+
+	if err = Verify(fs, getLogCtx(), "/cache", nil); err != nil {
+		t.Fatalf("unexpected error when invoking Verify: %v", err)
+	}
+
+	if _, err = fs.Stat(sha256Path); err != nil {
+		t.Errorf("expected sha256 entry to be verified with sha256, but got %v", err)
+	}
+}
+
+func TestListReturnsNothingWhenCacheDoesNotExist(t *testing.T) {
+	entries, err := List(afero.NewMemMapFs(), "/cache")
+	if err != nil {
+		t.Fatalf("unexpected error when invoking List: %v", err)
+	}
+
+	if len(entries) != 0 {
+		t.Errorf("expected no entries, but got %+v", entries)
+	}
+}
+
+func TestVerifyRemovesCorruptEntries(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	now := time.Now()
+
+	writeEntry(t, fs, fileAChecksum, "file a", now)
+	writeEntry(t, fs, fileBChecksum, "corrupt", now)
+
+	if err := Verify(fs, getLogCtx(), "/cache", nil); err != nil {
+		t.Fatalf("unexpected error when invoking Verify: %v", err)
+	}
+
+	if _, err := fs.Stat(getPath(fileAChecksum)); err != nil {
+		t.Errorf("expected valid entry to be kept, but got %v", err)
+	}
+
+	if _, err := fs.Stat(getPath(fileBChecksum)); !os.IsNotExist(err) {
+		t.Errorf("expected corrupt entry to be removed, but got %v", err)
+	}
+
+	if _, err := fs.Stat(dependency.GetCacheUsePath(getPath(fileBChecksum))); !os.IsNotExist(err) {
+		t.Errorf("expected corrupt entry's recorded use to be removed, but got %v", err)
+	}
+}
+
+func TestGCOlderThan(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	now := time.Date(2021, 1, 10, 0, 0, 0, 0, time.UTC)
+	day := 24 * time.Hour
+
+	writeEntry(t, fs, fileAChecksum, "file a", now.Add(-48*time.Hour))
+	writeEntry(t, fs, fileBChecksum, "file b", now.Add(-48*time.Hour))
+	writeEntry(t, fs, fileCChecksum, "file c", now)
+
+	deps := []dependency.Dependency{
+		dependency.Executable{Name: "bin/a", Location: "some.sh/a", Checksum: fileAChecksum},
+	}
+
+	if err := GC(fs, getLogCtx(), "/cache", nil, deps, GCPolicy{OlderThan: &day}, now); err != nil {
+		t.Fatalf("unexpected error when invoking GC: %v", err)
+	}
+
+	if _, err := fs.Stat(getPath(fileAChecksum)); err != nil {
+		t.Errorf("expected entry referenced by lockal.star to be kept, but got %v", err)
+	}
+
+	if _, err := fs.Stat(getPath(fileBChecksum)); !os.IsNotExist(err) {
+		t.Errorf("expected old entry to be removed, but got %v", err)
+	}
+
+	if _, err := fs.Stat(getPath(fileCChecksum)); err != nil {
+		t.Errorf("expected recently used entry to be kept, but got %v", err)
+	}
+}
+
+func TestGCOlderThanZeroRemovesEveryUnreferencedEntry(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	now := time.Date(2021, 1, 10, 0, 0, 0, 0, time.UTC)
+	olderThan := time.Duration(0)
+
+	writeEntry(t, fs, fileAChecksum, "file a", now.Add(-time.Second))
+	writeEntry(t, fs, fileBChecksum, "file b", now.Add(-time.Second))
+
+	deps := []dependency.Dependency{
+		dependency.Executable{Name: "bin/a", Location: "some.sh/a", Checksum: fileAChecksum},
+	}
+
+	if err := GC(fs, getLogCtx(), "/cache", nil, deps, GCPolicy{OlderThan: &olderThan}, now); err != nil {
+		t.Fatalf("unexpected error when invoking GC: %v", err)
+	}
+
+	if _, err := fs.Stat(getPath(fileAChecksum)); err != nil {
+		t.Errorf("expected entry referenced by lockal.star to be kept, but got %v", err)
+	}
+
+	if _, err := fs.Stat(getPath(fileBChecksum)); !os.IsNotExist(err) {
+		t.Errorf("expected unreferenced entry to be removed, but got %v", err)
+	}
+}
+
+func TestGCRemovesEntriesWhileLockedAndKeepsLockFile(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	now := time.Date(2021, 1, 10, 0, 0, 0, 0, time.UTC)
+	day := 24 * time.Hour
+
+	writeEntry(t, fs, fileAChecksum, "file a", now.Add(-48*time.Hour))
+
+	lockPath := dependency.GetCacheLockPath(getPath(fileAChecksum))
+	lockedPaths := []string{}
+
+	lockFile := func(path string, logCtx *log.Entry) (func(), error) {
+		if _, err := fs.Stat(getPath(fileAChecksum)); err != nil {
+			t.Errorf("expected entry to be locked before being removed, but got %v", err)
+		}
+
+		lockedPaths = append(lockedPaths, path)
+
+		return func() {}, afero.WriteFile(fs, path, []byte{}, 0644)
+	}
+
+	if err := GC(fs, getLogCtx(), "/cache", lockFile, nil, GCPolicy{OlderThan: &day}, now); err != nil {
+		t.Fatalf("unexpected error when invoking GC: %v", err)
+	}
+
+	if len(lockedPaths) != 1 || lockedPaths[0] != lockPath {
+		t.Errorf("expected %s to be locked, but got %v", lockPath, lockedPaths)
+	}
+
+	if _, err := fs.Stat(getPath(fileAChecksum)); !os.IsNotExist(err) {
+		t.Errorf("expected entry to be removed, but got %v", err)
+	}
+
+	if _, err := fs.Stat(lockPath); err != nil {
+		t.Errorf("expected lock file to be kept for processes waiting on it, but got %v", err)
+	}
+}
+
+func TestGCMaxSizeRemovesLeastRecentlyUsedFirst(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	now := time.Date(2021, 1, 10, 0, 0, 0, 0, time.UTC)
+	maxSize := int64(12)
+
+	writeEntry(t, fs, fileAChecksum, "file a", now.Add(-3*time.Hour))
+	writeEntry(t, fs, fileBChecksum, "file b", now.Add(-2*time.Hour))
+	writeEntry(t, fs, fileCChecksum, "file c", now.Add(-1*time.Hour))
+
+	if err := GC(fs, getLogCtx(), "/cache", nil, nil, GCPolicy{MaxSize: &maxSize}, now); err != nil {
+		t.Fatalf("unexpected error when invoking GC: %v", err)
+	}
+
+	if _, err := fs.Stat(getPath(fileAChecksum)); !os.IsNotExist(err) {
+		t.Errorf("expected least recently used entry to be removed, but got %v", err)
+	}
+
+	for _, checksum := range []string{fileBChecksum, fileCChecksum} {
+		if _, err := fs.Stat(getPath(checksum)); err != nil {
+			t.Errorf("expected %s to be kept, but got %v", checksum, err)
+		}
+	}
+}
+
+func TestParseSize(t *testing.T) {
+	tests := map[string]int64{
+		"100":   100,
+		"10B":   10,
+		"2KB":   2048,
+		"1.5MB": 1572864,
+		"10gb":  10737418240,
+	}
+
+	for size, expected := range tests {
+		actual, err := ParseSize(size)
+		if err != nil {
+			t.Fatalf("unexpected error when invoking ParseSize(%s): %v", size, err)
+		}
+
+		if actual != expected {
+			t.Errorf("expected ParseSize(%s) to be %d, but got %d", size, expected, actual)
+		}
+	}
+
+	_, err := ParseSize("lots")
+	if err == nil {
+		t.Fatal("expected an error for an invalid size")
+	}
+
+	if err.Error() != "invalid size lots, expected a size such as 500MB or 10GB" {
+		t.Errorf("unexpected error message: %s", err.Error())
+	}
+}
+
+func TestParseAge(t *testing.T) {
+	tests := map[string]time.Duration{
+		"30d": 30 * 24 * time.Hour,
+		"12h": 12 * time.Hour,
+	}
+
+	for age, expected := range tests {
+		actual, err := ParseAge(age)
+		if err != nil {
+			t.Fatalf("unexpected error when invoking ParseAge(%s): %v", age, err)
+		}
+
+		if actual != expected {
+			t.Errorf("expected ParseAge(%s) to be %s, but got %s", age, expected, actual)
+		}
+	}
+
+	_, err := ParseAge("a while")
+	if err == nil {
+		t.Fatal("expected an error for an invalid age")
+	}
+
+	if err.Error() != "invalid age a while, expected an age such as 30d or 12h" {
+		t.Errorf("unexpected error message: %s", err.Error())
+	}
+}
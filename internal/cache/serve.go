@@ -0,0 +1,89 @@
+package cache
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+
+	"github.com/apex/log"
+	"github.com/spf13/afero"
+
+	"github.com/dustinspecker/lockal/internal/digest"
+)
+
+var pathPattern = regexp.MustCompile("^/([a-z0-9]+)/([0-9a-f]+)$")
+
+// NewHandler serves the cache read-only in the same layout used by remote caches, /ALGORITHM/CHECKSUM
+func NewHandler(fs afero.Fs, logCtx *log.Entry, cacheDir string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestLogCtx := logCtx.WithFields(log.Fields{
+			"method": r.Method,
+			"path":   r.URL.Path,
+			"remote": r.RemoteAddr,
+		})
+
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			http.Error(w, "cache is read-only", http.StatusMethodNotAllowed)
+
+			requestLogCtx.Warn("rejected request since cache is read-only")
+
+			return
+		}
+
+		match := pathPattern.FindStringSubmatch(r.URL.Path)
+		if match == nil {
+			http.NotFound(w, r)
+
+			requestLogCtx.Info("miss")
+
+			return
+		}
+
+		algorithm, checksum := match[1], match[2]
+
+		if _, _, err := digest.Parse(fmt.Sprintf("%s:%s", algorithm, checksum)); err != nil {
+			http.NotFound(w, r)
+
+			requestLogCtx.Info("miss")
+
+			return
+		}
+
+		file, err := fs.Open(filepath.Join(getCacheRoot(cacheDir), algorithm, checksum[0:2], checksum))
+		if os.IsNotExist(err) {
+			http.NotFound(w, r)
+
+			requestLogCtx.Info("miss")
+
+			return
+		}
+		if err != nil {
+			http.Error(w, "unable to read cache", http.StatusInternalServerError)
+
+			requestLogCtx.WithError(err).Error("unable to read cache")
+
+			return
+		}
+		defer file.Close()
+
+		stat, err := file.Stat()
+		if err != nil {
+			http.Error(w, "unable to read cache", http.StatusInternalServerError)
+
+			requestLogCtx.WithError(err).Error("unable to read cache")
+
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/octet-stream")
+		w.Header().Set("ETag", fmt.Sprintf(`"%s:%s"`, algorithm, checksum))
+
+		requestLogCtx.WithField("size", stat.Size()).Info("hit")
+
+		// ServeContent streams the file and handles Range and conditional requests
+		http.ServeContent(w, r, checksum, stat.ModTime(), file)
+	})
+}
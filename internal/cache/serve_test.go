@@ -0,0 +1,84 @@
+package cache
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/apex/log"
+	"github.com/apex/log/handlers/memory"
+	"github.com/spf13/afero"
+)
+
+func TestHandler(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	writeEntry(t, fs, fileAChecksum, "file a", time.Now())
+
+	log.SetLevel(log.DebugLevel)
+	logHandler := memory.New()
+	log.SetHandler(logHandler)
+
+	server := httptest.NewServer(NewHandler(afero.NewReadOnlyFs(fs), log.WithField("app", "lockal-test"), "/cache"))
+	defer server.Close()
+
+	tests := []struct {
+		method         string
+		path           string
+		rangeHeader    string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{http.MethodGet, "/sha512/" + fileAChecksum, "", http.StatusOK, "file a"},
+		{http.MethodGet, "/sha512/" + fileAChecksum, "bytes=5-", http.StatusPartialContent, "a"},
+		{http.MethodGet, "/sha512/" + fileBChecksum, "", http.StatusNotFound, "404 page not found\n"},
+		{http.MethodGet, "/sha512/../../secret", "", http.StatusNotFound, "404 page not found\n"},
+		{http.MethodPut, "/sha512/" + fileAChecksum, "", http.StatusMethodNotAllowed, "cache is read-only\n"},
+	}
+
+	for _, test := range tests {
+		request, err := http.NewRequest(test.method, server.URL+test.path, nil)
+		if err != nil {
+			t.Fatalf("unexpected error creating request: %v", err)
+		}
+
+		if test.rangeHeader != "" {
+			request.Header.Set("Range", test.rangeHeader)
+		}
+
+		response, err := http.DefaultClient.Do(request)
+		if err != nil {
+			t.Fatalf("unexpected error sending request: %v", err)
+		}
+
+		body, err := ioutil.ReadAll(response.Body)
+		response.Body.Close()
+		if err != nil {
+			t.Fatalf("unexpected error reading response: %v", err)
+		}
+
+		if response.StatusCode != test.expectedStatus {
+			t.Errorf("expected %s %s to respond with %d, but got %d", test.method, test.path, test.expectedStatus, response.StatusCode)
+		}
+
+		if string(body) != test.expectedBody {
+			t.Errorf("expected %s %s to respond with %q, but got %q", test.method, test.path, test.expectedBody, string(body))
+		}
+	}
+
+	hits := 0
+	misses := 0
+	for _, entry := range logHandler.Entries {
+		switch entry.Message {
+		case "hit":
+			hits++
+		case "miss":
+			misses++
+		}
+	}
+
+	if hits != 2 || misses != 2 {
+		t.Errorf("expected 2 hits and 2 misses to be logged, but got %d hits and %d misses", hits, misses)
+	}
+}
@@ -0,0 +1,106 @@
+package checksum
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"path"
+
+	"github.com/dustinspecker/lockal/internal/config"
+	"github.com/dustinspecker/lockal/internal/dependency"
+)
+
+// Run downloads location to the cache and writes a rule for it to out.
+//
+// If location is an archive and extractFilepath is empty, the files within the archive are written to out instead.
+// If name is empty, the rule is named after the downloaded or extracted file within a bin directory.
+func Run(cfg config.Config, out io.Writer, location, name, extractFilepath string) error {
+	archiveChecksum, cachePath, err := dependency.DownloadToCache(cfg, fmt.Sprintf("%s?archive=false", location))
+	if err != nil {
+		return err
+	}
+
+	files, err := cfg.ListFilesInArchive(location, cachePath)
+	if errors.Is(err, config.ErrNotArchive) {
+		if extractFilepath != "" {
+			return fmt.Errorf("unable to extract %s since %s is not a supported archive", extractFilepath, location)
+		}
+
+		if name == "" {
+			name, err = getDefaultName(location)
+			if err != nil {
+				return err
+			}
+		}
+
+		_, err = fmt.Fprintf(out, `executable(
+  name = "%s",
+  location = "%s",
+  checksum = "%s",
+)
+`, name, location, archiveChecksum)
+
+		return err
+	}
+	if err != nil {
+		return err
+	}
+
+	if extractFilepath == "" {
+		if _, err = fmt.Fprintf(out, "# %s is an archive, provide one of the following files as extract filepath:\n", location); err != nil {
+			return err
+		}
+
+		for _, file := range files {
+			if _, err = fmt.Fprintf(out, "#   %s\n", file); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	if !contains(files, extractFilepath) {
+		return fmt.Errorf("%s does not exist in %s", extractFilepath, location)
+	}
+
+	executableChecksum, _, err := dependency.ExtractToCache(cfg, location, cachePath, extractFilepath)
+	if err != nil {
+		return err
+	}
+
+	if name == "" {
+		name = fmt.Sprintf("bin/%s", path.Base(extractFilepath))
+	}
+
+	_, err = fmt.Fprintf(out, `executable_from_archive(
+  name = "%s",
+  location = "%s",
+  archive_checksum = "%s",
+  extract_filepath = "%s",
+  executable_checksum = "%s",
+)
+`, name, location, archiveChecksum, extractFilepath, executableChecksum)
+
+	return err
+}
+
+func getDefaultName(location string) (string, error) {
+	locationURL, err := url.Parse(location)
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("bin/%s", path.Base(locationURL.Path)), nil
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,123 @@
+package checksum
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/apex/log"
+	"github.com/apex/log/handlers/memory"
+	"github.com/spf13/afero"
+
+	"github.com/dustinspecker/lockal/internal/config"
+)
+
+func getConfig(fs afero.Fs) config.Config {
+	log.SetLevel(log.DebugLevel)
+	log.SetHandler(memory.New())
+
+	return config.Config{
+		CacheDir: "/.cache",
+		Fs:       fs,
+		LogCtx: log.WithFields(log.Fields{
+			"app": "lockal-test",
+		}),
+		GetFile: func(dest, src string, hash io.Writer) error {
+			switch src {
+			case "https://some.sh/tools/ghostdog?archive=false":
+				return afero.WriteFile(fs, dest, []byte("file a"), 0644)
+			case "https://some.sh/archive.tgz?archive=false":
+				return afero.WriteFile(fs, dest, []byte("an archive"), 0644)
+			}
+
+			return fmt.Errorf("unexpected src %s", src)
+		},
+		ExtractFileFromArchive: func(archiveFileName, archivePath, extractFilepath, extractToDir string) error {
+			return afero.WriteFile(fs, fmt.Sprintf("%s/%s", extractToDir, extractFilepath), []byte("an executable"), 0644)
+		},
+		ListFilesInArchive: func(archiveFileName, archivePath string) ([]string, error) {
+			if archiveFileName != "https://some.sh/archive.tgz" {
+				return nil, config.ErrNotArchive
+			}
+
+			return []string{"artifacts/readme.md", "artifacts/executable"}, nil
+		},
+	}
+}
+
+func TestRunForExecutable(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	out := &bytes.Buffer{}
+
+	if err := Run(getConfig(fs), out, "https://some.sh/tools/ghostdog", "", ""); err != nil {
+		t.Fatalf("unexpected error when invoking Run: %v", err)
+	}
+
+	expectedOutput := `executable(
+  name = "bin/ghostdog",
+  location = "https://some.sh/tools/ghostdog",
+  checksum = "a705aaf587ddc9ed135d4c318c339f3a0d6eb3a2e11936942afbfcd65254da6a1600b7b8e27f59464219fdc704f3b96c9953d80c05632411f475eea6f4548963",
+)
+`
+	if out.String() != expectedOutput {
+		t.Errorf("expected output of:\n%s\nbut got:\n%s", expectedOutput, out.String())
+	}
+
+	if _, err := fs.Stat("/.cache/lockal/sha512/a7/a705aaf587ddc9ed135d4c318c339f3a0d6eb3a2e11936942afbfcd65254da6a1600b7b8e27f59464219fdc704f3b96c9953d80c05632411f475eea6f4548963"); err != nil {
+		t.Errorf("expected download to be cached, but got %v", err)
+	}
+}
+
+func TestRunListsFilesInArchive(t *testing.T) {
+	out := &bytes.Buffer{}
+
+	if err := Run(getConfig(afero.NewMemMapFs()), out, "https://some.sh/archive.tgz", "", ""); err != nil {
+		t.Fatalf("unexpected error when invoking Run: %v", err)
+	}
+
+	expectedOutput := `# https://some.sh/archive.tgz is an archive, provide one of the following files as extract filepath:
+#   artifacts/readme.md
+#   artifacts/executable
+`
+	if out.String() != expectedOutput {
+		t.Errorf("expected output of:\n%s\nbut got:\n%s", expectedOutput, out.String())
+	}
+}
+
+func TestRunForExecutableFromArchive(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	out := &bytes.Buffer{}
+
+	if err := Run(getConfig(fs), out, "https://some.sh/archive.tgz", "bin/exe", "artifacts/executable"); err != nil {
+		t.Fatalf("unexpected error when invoking Run: %v", err)
+	}
+
+	expectedOutput := `executable_from_archive(
+  name = "bin/exe",
+  location = "https://some.sh/archive.tgz",
+  archive_checksum = "21b9c6c34401c466769ec75e894d47f3d5eb656358ae836dc6d87b7747af69377f8266913427dfcd0027e68873ae8962f8afd943a29ccfacacabd27113a981be",
+  extract_filepath = "artifacts/executable",
+  executable_checksum = "bc07ffe5b4dbd2c52c87bce5298893c63e38a0d0333e2e01bbcfeddfdd40602724400d2998cb2a75e216aaffc913306a908d6057729a76102086b19556dc8be2",
+)
+`
+	if out.String() != expectedOutput {
+		t.Errorf("expected output of:\n%s\nbut got:\n%s", expectedOutput, out.String())
+	}
+
+	if _, err := fs.Stat("/.cache/lockal/sha512/bc/bc07ffe5b4dbd2c52c87bce5298893c63e38a0d0333e2e01bbcfeddfdd40602724400d2998cb2a75e216aaffc913306a908d6057729a76102086b19556dc8be2"); err != nil {
+		t.Errorf("expected extracted executable to be cached, but got %v", err)
+	}
+}
+
+func TestRunReturnsErrorWhenExtractFilepathIsNotInArchive(t *testing.T) {
+	err := Run(getConfig(afero.NewMemMapFs()), &bytes.Buffer{}, "https://some.sh/archive.tgz", "", "artifacts/missing")
+	if err == nil {
+		t.Fatal("expected an error when extract filepath is not in archive")
+	}
+
+	expectedErrorMessage := "artifacts/missing does not exist in https://some.sh/archive.tgz"
+	if err.Error() != expectedErrorMessage {
+		t.Errorf("expected error message of \"%s\", but got \"%s\"", expectedErrorMessage, err.Error())
+	}
+}
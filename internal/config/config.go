@@ -1,14 +1,26 @@
 package config
 
 import (
+	"errors"
+	"io"
+
 	"github.com/apex/log"
 	"github.com/spf13/afero"
 )
 
+// ErrNotArchive is returned by ListFilesInArchive when a file is not a supported archive
+var ErrNotArchive = errors.New("not a supported archive")
+
 type Config struct {
 	CacheDir               string
+	Project                string
 	Fs                     afero.Fs
 	LogCtx                 *log.Entry
-	GetFile                func(dest, src string) error
+	GetFile                func(dest, src string, hash io.Writer) error
 	ExtractFileFromArchive func(archiveFileName, archivePath, extractFilepath, extractToDir string) error
+	ListFilesInArchive     func(archiveFileName, archivePath string) ([]string, error)
+	LockFile               func(path string, logCtx *log.Entry) (func(), error)
+	RemoteCache            string
+	RemoteCacheUpload      bool
+	PutFile                func(dest string, content io.Reader, size int64) error
 }
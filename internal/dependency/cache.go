@@ -0,0 +1,187 @@
+package dependency
+
+import (
+	"encoding/json"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/spf13/afero"
+
+	"github.com/dustinspecker/lockal/internal/config"
+	"github.com/dustinspecker/lockal/internal/digest"
+)
+
+// CacheUse records the last time a cache entry was used and by what, so the cache can be listed and garbage collected
+type CacheUse struct {
+	LastUsed time.Time `json:"last_used"`
+	Project  string    `json:"project"`
+	Rule     string    `json:"rule"`
+}
+
+// GetCacheUsePath returns where the CacheUse of the cache entry at cachePath is recorded
+func GetCacheUsePath(cachePath string) string {
+	return fmt.Sprintf("%s.json", cachePath)
+}
+
+// ReadCacheUse returns the recorded CacheUse of the cache entry at cachePath, false is returned if none is recorded
+func ReadCacheUse(fs afero.Fs, cachePath string) (CacheUse, bool, error) {
+	cacheUse := CacheUse{}
+
+	content, err := afero.ReadFile(fs, GetCacheUsePath(cachePath))
+	if os.IsNotExist(err) {
+		return cacheUse, false, nil
+	}
+	if err != nil {
+		return cacheUse, false, err
+	}
+
+	if err = json.Unmarshal(content, &cacheUse); err != nil {
+		return cacheUse, false, err
+	}
+
+	return cacheUse, true, nil
+}
+
+// recordCacheUse notes that rule used the cache entry at cachePath. Failing to record is logged rather than returned
+// since it shouldn't prevent installing.
+func recordCacheUse(cfg config.Config, cachePath, rule string) {
+	content, err := json.Marshal(CacheUse{
+		LastUsed: time.Now().UTC(),
+		Project:  cfg.Project,
+		Rule:     rule,
+	})
+	if err == nil {
+		var tempPath string
+
+		tempPath, _, err = writeTempFile(cfg.Fs, filepath.Dir(cachePath), digest.Default, func(tempFile afero.File, fileHash hash.Hash) error {
+			_, err := tempFile.Write(content)
+
+			return err
+		})
+		if err == nil {
+			err = cfg.Fs.Rename(tempPath, GetCacheUsePath(cachePath))
+		}
+	}
+
+	if err != nil {
+		cfg.LogCtx.WithError(err).Warn(fmt.Sprintf("unable to record use of %s", cachePath))
+	}
+}
+
+// DownloadToCache downloads location to the cache without knowing its checksum ahead of time.
+// The sha512 checksum and cache path of the downloaded file are returned.
+func DownloadToCache(cfg config.Config, location string) (string, string, error) {
+	cfg.LogCtx.Info(fmt.Sprintf("downloading %s", location))
+
+	return writeToCache(cfg, downloadTo(cfg.Fs, location, cfg.GetFile))
+}
+
+// ExtractToCache extracts extractFilepath from the archive at archivePath to the cache without knowing its checksum
+// ahead of time. The sha512 checksum and cache path of the extracted file are returned.
+func ExtractToCache(cfg config.Config, archiveFileName, archivePath, extractFilepath string) (string, string, error) {
+	tempDir, err := afero.TempDir(cfg.Fs, "", "")
+	if err != nil {
+		return "", "", err
+	}
+	defer cfg.Fs.RemoveAll(tempDir)
+
+	cfg.LogCtx.Info(fmt.Sprintf("extracting %s from %s", extractFilepath, archivePath))
+
+	if err = cfg.ExtractFileFromArchive(archiveFileName, archivePath, extractFilepath, tempDir); err != nil {
+		return "", "", err
+	}
+
+	return writeToCache(cfg, streamFrom(cfg.Fs, fmt.Sprintf("%s/%s", tempDir, extractFilepath)))
+}
+
+// ImportToCache writes content to the cache if it matches checksum, an error is returned if it doesn't
+func ImportToCache(cfg config.Config, checksum string, content io.Reader) error {
+	cachePath, err := getCachePath(cfg.CacheDir, checksum)
+	if err != nil {
+		return err
+	}
+
+	unlock, err := LockCachePath(cfg.LogCtx, cachePath, cfg.LockFile)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	cached, err := validateCacheEntry(cfg.Fs, cfg.LogCtx, cachePath, checksum)
+	if err != nil || cached {
+		return err
+	}
+
+	if err = cfg.Fs.MkdirAll(filepath.Dir(cachePath), 0755); err != nil {
+		return err
+	}
+
+	valid, err := writeVerifiedFile(cfg.Fs, cfg.LogCtx, cachePath, checksum, 0644, func(tempFile afero.File, fileHash hash.Hash) error {
+		_, err := io.Copy(tempFile, io.TeeReader(content, fileHash))
+
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	if !valid {
+		return fmt.Errorf("imported %s did not match expected checksum", checksum)
+	}
+
+	return nil
+}
+
+// ImportSignatureToCache writes the signature of the cache entry with checksum to the cache. The signature is verified
+// when the cache entry is used since that's when the public key is known.
+func ImportSignatureToCache(cfg config.Config, checksum string, content io.Reader) error {
+	cachePath, err := getCachePath(cfg.CacheDir, checksum)
+	if err != nil {
+		return err
+	}
+
+	tempPath, _, err := writeTempFile(cfg.Fs, filepath.Dir(cachePath), digest.Default, func(tempFile afero.File, fileHash hash.Hash) error {
+		_, err := io.Copy(tempFile, content)
+
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	defer cfg.Fs.Remove(tempPath)
+
+	return cfg.Fs.Rename(tempPath, GetSignaturePath(cachePath))
+}
+
+func writeToCache(cfg config.Config, write func(tempFile afero.File, fileHash hash.Hash) error) (string, string, error) {
+	tempPath, checksum, err := writeTempFile(cfg.Fs, fmt.Sprintf("%s/lockal/%s", cfg.CacheDir, digest.Default), digest.Default, write)
+	if err != nil {
+		return "", "", err
+	}
+	defer cfg.Fs.Remove(tempPath)
+
+	cachePath, err := getCachePath(cfg.CacheDir, checksum)
+	if err != nil {
+		return "", "", err
+	}
+
+	unlock, err := LockCachePath(cfg.LogCtx, cachePath, cfg.LockFile)
+	if err != nil {
+		return "", "", err
+	}
+	defer unlock()
+
+	if err = cfg.Fs.MkdirAll(filepath.Dir(cachePath), 0755); err != nil {
+		return "", "", err
+	}
+
+	if err = cfg.Fs.Chmod(tempPath, 0644); err != nil {
+		return "", "", err
+	}
+
+	return checksum, cachePath, cfg.Fs.Rename(tempPath, cachePath)
+}
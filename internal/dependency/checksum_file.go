@@ -0,0 +1,196 @@
+package dependency
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/spf13/afero"
+
+	"github.com/dustinspecker/lockal/internal/config"
+	"github.com/dustinspecker/lockal/internal/digest"
+)
+
+// ChecksumFile is a file listing checksums, such as SHA256SUMS, that a dependency's checksum is read from instead of
+// being written in lockal.star
+type ChecksumFile struct {
+	// Location to download the checksum file from
+	Location string
+	// Checksum of the checksum file itself, so it can't change without lockal.star changing
+	Checksum string
+	// Entry is the file name to use the checksum of, which defaults to the last element of the dependency's location
+	Entry string
+}
+
+// IsSet returns whether the dependency's checksum is read from a checksum file
+func (cf ChecksumFile) IsSet() bool {
+	return cf.Location != ""
+}
+
+// algorithmsByLength infers the algorithm of checksum files, which don't include it, from the length of each checksum.
+// A 128 character checksum is treated as a sha512 rather than a blake2b.
+var algorithmsByLength = map[int]string{
+	64:  "sha256",
+	96:  "sha384",
+	128: "sha512",
+}
+
+// ResolveChecksums returns deps with the checksums of any dependencies using a checksum file read from that file. Each
+// checksum file is downloaded to the cache and verified against its own checksum first.
+func ResolveChecksums(cfg config.Config, deps []Dependency) ([]Dependency, error) {
+	resolved := []Dependency{}
+
+	for _, dep := range deps {
+		depCfg := cfg
+		depCfg.LogCtx = cfg.LogCtx.WithField("dependency", dep.GetName())
+
+		switch dep := dep.(type) {
+		case Executable:
+			if dep.ChecksumFile.IsSet() {
+				checksum, err := dep.ChecksumFile.read(depCfg, dep.Location)
+				if err != nil {
+					return resolved, fmt.Errorf("%s: %w", dep.Name, err)
+				}
+
+				dep.Checksum = checksum
+			}
+
+			resolved = append(resolved, dep)
+		case ExecutableFromArchive:
+			if dep.ChecksumFile.IsSet() {
+				checksum, err := dep.ChecksumFile.read(depCfg, dep.Location)
+				if err != nil {
+					return resolved, fmt.Errorf("%s: %w", dep.Name, err)
+				}
+
+				dep.ArchiveChecksum = checksum
+			}
+
+			resolved = append(resolved, dep)
+		default:
+			resolved = append(resolved, dep)
+		}
+	}
+
+	return resolved, nil
+}
+
+// cache returns where the checksum file read by ResolveChecksums is cached, if the dependency uses one, and records
+// that rule used it. Uses aren't recorded when reading the checksum file since that may be done without modifying
+// anything, such as by lockal verify.
+func (cf ChecksumFile) cache(cfg config.Config, rule string) ([]string, error) {
+	if !cf.IsSet() {
+		return []string{}, nil
+	}
+
+	cachePath, err := getCachePath(cfg.CacheDir, cf.Checksum)
+	if err != nil {
+		return nil, err
+	}
+
+	recordCacheUse(cfg, cachePath, rule)
+
+	return []string{cachePath}, nil
+}
+
+// read downloads the checksum file to the cache and returns the checksum of the entry for location
+func (cf ChecksumFile) read(cfg config.Config, location string) (string, error) {
+	cachePath, err := getCachePath(cfg.CacheDir, cf.Checksum)
+	if err != nil {
+		return "", err
+	}
+
+	if err = downloadFile(cfg, cf.Location, cachePath, cf.Checksum); err != nil {
+		return "", err
+	}
+
+	content, err := afero.ReadFile(cfg.Fs, cachePath)
+	if err != nil {
+		return "", err
+	}
+
+	entry := cf.Entry
+	if entry == "" {
+		if entry, err = getEntryName(location); err != nil {
+			return "", err
+		}
+	}
+
+	checksum, err := parseChecksumFile(content, entry)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", cf.Location, err)
+	}
+
+	cfg.LogCtx.Debug(fmt.Sprintf("using checksum of %s from %s", entry, cf.Location))
+
+	return checksum, nil
+}
+
+// parseChecksumFile returns the checksum of entry from content in the format written by sha256sum and similar tools,
+// CHECKSUM  FILENAME on each line. A file with a single line without a file name, such as kind's .sha256 files, is
+// used for any entry.
+func parseChecksumFile(content []byte, entry string) (string, error) {
+	lines := []string{}
+
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line != "" && !strings.HasPrefix(line, "#") {
+			lines = append(lines, line)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return "", err
+	}
+
+	for _, line := range lines {
+		fields := strings.Fields(line)
+
+		if len(fields) == 1 && len(lines) == 1 {
+			return formatChecksum(fields[0])
+		}
+
+		if len(fields) != 2 {
+			continue
+		}
+
+		// binary mode is marked with a * before the file name
+		name := strings.TrimPrefix(strings.TrimPrefix(fields[1], "*"), "./")
+
+		if name == entry {
+			return formatChecksum(fields[0])
+		}
+	}
+
+	return "", fmt.Errorf("no checksum for %s", entry)
+}
+
+// formatChecksum prefixes value with its algorithm
+func formatChecksum(value string) (string, error) {
+	algorithm, ok := algorithmsByLength[len(value)]
+	if _, err := hex.DecodeString(value); err != nil || !ok {
+		return "", fmt.Errorf("unable to determine the algorithm of checksum %s", value)
+	}
+
+	return digest.Normalize(digest.Format(algorithm, value))
+}
+
+// getEntryName returns the file name location is published as in checksum files
+func getEntryName(location string) (string, error) {
+	parsedLocation, err := url.Parse(location)
+	if err != nil {
+		return "", err
+	}
+
+	name := path.Base(parsedLocation.Path)
+	if name == "." || name == "/" {
+		return "", fmt.Errorf("unable to determine the checksum entry for %s, provide checksum_entry", location)
+	}
+
+	return name, nil
+}
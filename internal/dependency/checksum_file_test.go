@@ -0,0 +1,132 @@
+package dependency
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/spf13/afero"
+
+	"github.com/dustinspecker/lockal/internal/config"
+)
+
+const (
+	sha256SUMS = `63c5cdfc617fb8fe93888e68674717590957a25b5e08219b1a61c3f031f88ee6  tool-linux-amd64
+1111111111111111111111111111111111111111111111111111111111111111  tool-darwin-amd64
+`
+	sha256SUMSChecksum = "ff0942231b4d4a42b3fd418c1c0d35143c36870e99a83106f6658dff117ef86de4e66d9670a46ed6c7a2feb117bece1907c4d4f445571b9f55c28677d1957dae"
+)
+
+func TestParseChecksumFile(t *testing.T) {
+	tests := []struct {
+		content  string
+		entry    string
+		expected string
+	}{
+		{sha256SUMS, "tool-darwin-amd64", "sha256:1111111111111111111111111111111111111111111111111111111111111111"},
+		{"# comment\n63C5CDFC617FB8FE93888E68674717590957A25B5E08219B1A61C3F031F88EE6 *./tool-linux-amd64\n", "tool-linux-amd64", "sha256:63c5cdfc617fb8fe93888e68674717590957a25b5e08219b1a61c3f031f88ee6"},
+		{"63c5cdfc617fb8fe93888e68674717590957a25b5e08219b1a61c3f031f88ee6\n", "anything", "sha256:63c5cdfc617fb8fe93888e68674717590957a25b5e08219b1a61c3f031f88ee6"},
+		{"a705aaf587ddc9ed135d4c318c339f3a0d6eb3a2e11936942afbfcd65254da6a1600b7b8e27f59464219fdc704f3b96c9953d80c05632411f475eea6f4548963  tool", "tool", "a705aaf587ddc9ed135d4c318c339f3a0d6eb3a2e11936942afbfcd65254da6a1600b7b8e27f59464219fdc704f3b96c9953d80c05632411f475eea6f4548963"},
+	}
+
+	for _, test := range tests {
+		actual, err := parseChecksumFile([]byte(test.content), test.entry)
+		if err != nil {
+			t.Fatalf("unexpected error when invoking parseChecksumFile for %s: %v", test.entry, err)
+		}
+
+		if actual != test.expected {
+			t.Errorf("expected checksum of %s to be %s, but got %s", test.entry, test.expected, actual)
+		}
+	}
+}
+
+func TestParseChecksumFileReturnsErrorForMissingEntry(t *testing.T) {
+	_, err := parseChecksumFile([]byte(sha256SUMS), "tool-windows-amd64")
+	if err == nil {
+		t.Fatal("expected an error for a missing entry")
+	}
+
+	if err.Error() != "no checksum for tool-windows-amd64" {
+		t.Errorf("unexpected error message: %s", err.Error())
+	}
+}
+
+func TestResolveChecksums(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	_, logCtx := getLogCtx()
+
+	downloads := 0
+
+	cfg := config.Config{
+		CacheDir: "/.cache",
+		Fs:       fs,
+		LogCtx:   logCtx,
+		GetFile: func(dest, src string, hash io.Writer) error {
+			downloads++
+
+			if src != "some.sh/SHA256SUMS" {
+				return fmt.Errorf("unexpected download of %s", src)
+			}
+
+			return afero.WriteFile(fs, dest, []byte(sha256SUMS), 0644)
+		},
+	}
+
+	checksumFile := ChecksumFile{Location: "some.sh/SHA256SUMS", Checksum: sha256SUMSChecksum}
+
+	deps := []Dependency{
+		Executable{Name: "bin/tool", Location: "some.sh/tool-linux-amd64", ChecksumFile: checksumFile},
+		ExecutableFromArchive{Name: "bin/other", Location: "some.sh/other.tgz", ExtractFilepath: "other", ExecutableChecksum: "exe_sum", ChecksumFile: ChecksumFile{Location: "some.sh/SHA256SUMS", Checksum: sha256SUMSChecksum, Entry: "tool-darwin-amd64"}},
+		Executable{Name: "bin/pinned", Location: "some.sh/pinned", Checksum: "pinned_sum"},
+	}
+
+	resolved, err := ResolveChecksums(cfg, deps)
+	if err != nil {
+		t.Fatalf("unexpected error when invoking ResolveChecksums: %v", err)
+	}
+
+	if checksum := resolved[0].(Executable).Checksum; checksum != "sha256:63c5cdfc617fb8fe93888e68674717590957a25b5e08219b1a61c3f031f88ee6" {
+		t.Errorf("expected checksum of bin/tool to be read from its location's entry, but got %s", checksum)
+	}
+
+	if checksum := resolved[1].(ExecutableFromArchive).ArchiveChecksum; checksum != "sha256:1111111111111111111111111111111111111111111111111111111111111111" {
+		t.Errorf("expected archive checksum of bin/other to be read from checksum_entry, but got %s", checksum)
+	}
+
+	if checksum := resolved[2].(Executable).Checksum; checksum != "pinned_sum" {
+		t.Errorf("expected checksum of bin/pinned to be unchanged, but got %s", checksum)
+	}
+
+	if downloads != 1 {
+		t.Errorf("expected checksum file to be downloaded once and then read from the cache, but was downloaded %d times", downloads)
+	}
+}
+
+func TestResolveChecksumsReturnsErrorWhenChecksumFileDoesNotMatchItsChecksum(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	_, logCtx := getLogCtx()
+
+	cfg := config.Config{
+		CacheDir: "/.cache",
+		Fs:       fs,
+		LogCtx:   logCtx,
+		GetFile: func(dest, src string, hash io.Writer) error {
+			return afero.WriteFile(fs, dest, []byte(strings.Replace(sha256SUMS, "1111", "2222", 1)), 0644)
+		},
+	}
+
+	deps := []Dependency{
+		Executable{Name: "bin/tool", Location: "some.sh/tool-linux-amd64", ChecksumFile: ChecksumFile{Location: "some.sh/SHA256SUMS", Checksum: sha256SUMSChecksum}},
+	}
+
+	_, err := ResolveChecksums(cfg, deps)
+	if err == nil {
+		t.Fatal("expected an error when the checksum file has changed")
+	}
+
+	if err.Error() != "bin/tool: downloaded /.cache/lockal/sha512/ff/"+sha256SUMSChecksum+" did not match expected checksum" {
+		t.Errorf("unexpected error message: %s", err.Error())
+	}
+}
@@ -4,6 +4,40 @@ import (
 	"github.com/dustinspecker/lockal/internal/config"
 )
 
+// Status describes an installed dependency compared to what lockal.star expects
+type Status string
+
+const (
+	StatusOK               Status = "ok"
+	StatusMissing          Status = "missing"
+	StatusChecksumMismatch Status = "checksum-mismatch"
+)
+
+// Action describes what Download would do to install a dependency
+type Action string
+
+const (
+	ActionSkip                     Action = "skip"
+	ActionCopyFromCache            Action = "copy-from-cache"
+	ActionExtractFromCachedArchive Action = "extract-from-cached-archive"
+	ActionDownload                 Action = "download"
+)
+
+// Plan describes what Download would do without doing any of it
+type Plan struct {
+	// Status of the currently installed dependency, a checksum mismatch means it would be replaced
+	Status Status
+	Action Action
+	// Source is the location to download from or the cache path to copy or extract from
+	Source string
+}
+
 type Dependency interface {
+	GetName() string
+	// Cache ensures every file needed to install the dependency is in the cache without installing it, the cache
+	// paths of those files are returned
+	Cache(config.Config) ([]string, error)
 	Download(config.Config) error
+	Plan(config.Config) (Plan, error)
+	Verify(config.Config) (Status, error)
 }
@@ -1,8 +1,6 @@
 package dependency
 
 import (
-	"fmt"
-
 	"github.com/dustinspecker/lockal/internal/config"
 )
 
@@ -10,6 +8,40 @@ type Executable struct {
 	Name     string
 	Location string
 	Checksum string
+	// ChecksumFile is where Checksum is read from by ResolveChecksums, if set
+	ChecksumFile ChecksumFile
+	// Signature of the downloaded file that must be valid, if set
+	Signature Signature
+}
+
+func (exe Executable) GetName() string {
+	return exe.Name
+}
+
+func (exe Executable) Cache(cfg config.Config) ([]string, error) {
+	cache, err := getCachePath(cfg.CacheDir, exe.Checksum)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = downloadFile(cfg, exe.Location, cache, exe.Checksum); err != nil {
+		return nil, err
+	}
+
+	if err = exe.Signature.verify(cfg, cache); err != nil {
+		return nil, err
+	}
+
+	recordCacheUse(cfg, cache, exe.Name)
+
+	checksumFileCaches, err := exe.ChecksumFile.cache(cfg, exe.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	caches := append([]string{cache}, exe.Signature.cache(cache)...)
+
+	return append(caches, checksumFileCaches...), nil
 }
 
 func (exe Executable) Download(cfg config.Config) error {
@@ -18,10 +50,9 @@ func (exe Executable) Download(cfg config.Config) error {
 	// check if dest file exists
 	// if dest file exists and checksum does match then do nothing
 	// if dest file exists and checksum does not match, remove the old dest file
-	// check cache for checksum, if not exist then download new file to cache
-	//	-> verify new file matches expected checksum, delete if no match
-	// copy file from cache to dest file
-	// mark dest file as executable
+	// check cache for checksum, if not exist or corrupt then download new file to cache
+	//	-> download to a temporary file, verify it matches expected checksum, then rename it into the cache
+	// copy file from cache to a temporary executable file, verify it, then rename it to dest file
 
 	existingFileIsValid, err := validateExistingFile(cfg.Fs, cfg.LogCtx, dest, exe.Checksum)
 	if err != nil {
@@ -32,14 +63,46 @@ func (exe Executable) Download(cfg config.Config) error {
 		return nil
 	}
 
-	cache := fmt.Sprintf("%s/lockal/sha512/%s/%s", cfg.CacheDir, exe.Checksum[0:2], exe.Checksum)
-	if err = downloadFile(cfg.Fs, cfg.LogCtx, exe.Location, cache, exe.Checksum, cfg.GetFile); err != nil {
+	cache, err := exe.Cache(cfg)
+	if err != nil {
 		return err
 	}
 
-	if err = copyFile(cfg.Fs, cfg.LogCtx, cache, dest); err != nil {
-		return err
+	return copyFromCache(cfg, cache[0], dest, exe.Checksum)
+}
+
+func (exe Executable) Verify(cfg config.Config) (Status, error) {
+	return VerifyFile(cfg.Fs, exe.Name, exe.Checksum)
+}
+
+func (exe Executable) Plan(cfg config.Config) (Plan, error) {
+	status, err := VerifyFile(cfg.Fs, exe.Name, exe.Checksum)
+	if err != nil {
+		return Plan{}, err
+	}
+
+	if status == StatusOK {
+		return Plan{Status: status, Action: ActionSkip}, nil
+	}
+
+	cache, err := getCachePath(cfg.CacheDir, exe.Checksum)
+	if err != nil {
+		return Plan{}, err
+	}
+
+	cached, err := isCached(cfg.Fs, cache, exe.Checksum)
+	if err != nil {
+		return Plan{}, err
+	}
+
+	signatureCached, err := exe.Signature.isCached(cfg.Fs, cache)
+	if err != nil {
+		return Plan{}, err
+	}
+
+	if cached && signatureCached {
+		return Plan{Status: status, Action: ActionCopyFromCache, Source: cache}, nil
 	}
 
-	return markFileExecutable(cfg.Fs, dest)
+	return Plan{Status: status, Action: ActionDownload, Source: exe.Location}, nil
 }
@@ -15,6 +15,51 @@ type ExecutableFromArchive struct {
 	ArchiveChecksum    string
 	ExtractFilepath    string
 	ExecutableChecksum string
+	// ChecksumFile is where ArchiveChecksum is read from by ResolveChecksums, if set
+	ChecksumFile ChecksumFile
+	// Signature of the downloaded archive that must be valid, if set
+	Signature Signature
+}
+
+func (efa ExecutableFromArchive) GetName() string {
+	return efa.Name
+}
+
+func (efa ExecutableFromArchive) Cache(cfg config.Config) ([]string, error) {
+	archiveCache, err := getCachePath(cfg.CacheDir, efa.ArchiveChecksum)
+	if err != nil {
+		return nil, err
+	}
+
+	executableCache, err := getCachePath(cfg.CacheDir, efa.ExecutableChecksum)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = downloadFile(cfg, fmt.Sprintf("%s?archive=false", efa.Location), archiveCache, efa.ArchiveChecksum); err != nil {
+		return nil, err
+	}
+
+	if err = efa.Signature.verify(cfg, archiveCache); err != nil {
+		return nil, err
+	}
+
+	recordCacheUse(cfg, archiveCache, efa.Name)
+
+	if err = extractFile(cfg.Fs, cfg.LogCtx, efa.Location, archiveCache, executableCache, efa.ExtractFilepath, efa.ExecutableChecksum, cfg.ExtractFileFromArchive, cfg.LockFile); err != nil {
+		return nil, err
+	}
+
+	recordCacheUse(cfg, executableCache, efa.Name)
+
+	checksumFileCaches, err := efa.ChecksumFile.cache(cfg, efa.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	caches := append([]string{archiveCache, executableCache}, efa.Signature.cache(archiveCache)...)
+
+	return append(caches, checksumFileCaches...), nil
 }
 
 func (efa ExecutableFromArchive) Download(cfg config.Config) error {
@@ -23,12 +68,11 @@ func (efa ExecutableFromArchive) Download(cfg config.Config) error {
 	// check if dest file exists
 	// if dest file exists and checksum does match then do nothing
 	// if dest file exists and checksum does not match, remove the old dest file
-	// check cache for executable checksum, if not exist then extract from archive in cache
-	//  -> check cache for archive checksum, if not exist then download new archive file to cache
-	//	-> verify new archive file matches expected checksum, delete if no match
-	// extract filepath from archive in cache to executable cache
-	// copy executable file from cache to dest file
-	// mark dest file as executable
+	// check cache for executable checksum, if it exists and isn't corrupt then skip downloading and extracting the archive
+	//  -> check cache for archive checksum, if not exist or corrupt then download new archive file to cache
+	//	-> download to a temporary file, verify it matches expected checksum, then rename it into the cache
+	//  -> extract filepath from archive in cache, verify it, then rename it into executable cache
+	// copy executable file from cache to a temporary executable file, verify it, then rename it to dest file
 
 	existingFileIsValid, err := validateExistingFile(cfg.Fs, cfg.LogCtx, dest, efa.ExecutableChecksum)
 	if err != nil {
@@ -39,30 +83,120 @@ func (efa ExecutableFromArchive) Download(cfg config.Config) error {
 		return nil
 	}
 
-	archiveCache := fmt.Sprintf("%s/lockal/sha512/%s/%s", cfg.CacheDir, efa.ArchiveChecksum[0:2], efa.ArchiveChecksum)
-	if err = downloadFile(cfg.Fs, cfg.LogCtx, fmt.Sprintf("%s?archive=false", efa.Location), archiveCache, efa.ArchiveChecksum, cfg.GetFile); err != nil {
+	executableCache, err := getCachePath(cfg.CacheDir, efa.ExecutableChecksum)
+	if err != nil {
 		return err
 	}
 
-	executableCache := fmt.Sprintf("%s/lockal/sha512/%s/%s", cfg.CacheDir, efa.ExecutableChecksum[0:2], efa.ExecutableChecksum)
-	if err = extractFile(cfg.Fs, cfg.LogCtx, efa.Location, archiveCache, executableCache, efa.ExtractFilepath, efa.ExecutableChecksum, cfg.ExtractFileFromArchive); err != nil {
+	archiveCache, err := getCachePath(cfg.CacheDir, efa.ArchiveChecksum)
+	if err != nil {
 		return err
 	}
 
-	if err = copyFile(cfg.Fs, cfg.LogCtx, executableCache, dest); err != nil {
+	executableCached, err := validateCacheEntry(cfg.Fs, cfg.LogCtx, executableCache, efa.ExecutableChecksum)
+	if err != nil {
 		return err
 	}
 
-	return markFileExecutable(cfg.Fs, dest)
+	// the signature is of the archive, so a signed archive is still needed when its executable was already extracted
+	if executableCached && !efa.Signature.IsSet() {
+		cfg.LogCtx.Info(fmt.Sprintf("skipping download for %s as %s already exists in cache", efa.Location, efa.ExtractFilepath))
+	} else {
+		if err = downloadFile(cfg, fmt.Sprintf("%s?archive=false", efa.Location), archiveCache, efa.ArchiveChecksum); err != nil {
+			return err
+		}
+
+		if err = efa.Signature.verify(cfg, archiveCache); err != nil {
+			return err
+		}
+
+		recordCacheUse(cfg, archiveCache, efa.Name)
+
+		if err = extractFile(cfg.Fs, cfg.LogCtx, efa.Location, archiveCache, executableCache, efa.ExtractFilepath, efa.ExecutableChecksum, cfg.ExtractFileFromArchive, cfg.LockFile); err != nil {
+			return err
+		}
+	}
+
+	recordCacheUse(cfg, executableCache, efa.Name)
+
+	return copyFromCache(cfg, executableCache, dest, efa.ExecutableChecksum)
+}
+
+func (efa ExecutableFromArchive) Verify(cfg config.Config) (Status, error) {
+	return VerifyFile(cfg.Fs, efa.Name, efa.ExecutableChecksum)
+}
+
+func (efa ExecutableFromArchive) Plan(cfg config.Config) (Plan, error) {
+	status, err := VerifyFile(cfg.Fs, efa.Name, efa.ExecutableChecksum)
+	if err != nil {
+		return Plan{}, err
+	}
+
+	if status == StatusOK {
+		return Plan{Status: status, Action: ActionSkip}, nil
+	}
+
+	executableCache, err := getCachePath(cfg.CacheDir, efa.ExecutableChecksum)
+	if err != nil {
+		return Plan{}, err
+	}
+
+	archiveCache, err := getCachePath(cfg.CacheDir, efa.ArchiveChecksum)
+	if err != nil {
+		return Plan{}, err
+	}
+
+	executableCached, err := isCached(cfg.Fs, executableCache, efa.ExecutableChecksum)
+	if err != nil {
+		return Plan{}, err
+	}
+
+	archiveCached, err := isCached(cfg.Fs, archiveCache, efa.ArchiveChecksum)
+	if err != nil {
+		return Plan{}, err
+	}
+
+	signatureCached, err := efa.Signature.isCached(cfg.Fs, archiveCache)
+	if err != nil {
+		return Plan{}, err
+	}
+
+	// verifying the signature of a cached executable requires its archive
+	if executableCached && (!efa.Signature.IsSet() || archiveCached && signatureCached) {
+		return Plan{Status: status, Action: ActionCopyFromCache, Source: executableCache}, nil
+	}
+
+	if archiveCached && signatureCached {
+		return Plan{Status: status, Action: ActionExtractFromCachedArchive, Source: archiveCache}, nil
+	}
+
+	return Plan{Status: status, Action: ActionDownload, Source: efa.Location}, nil
 }
 
-func extractFile(fs afero.Fs, logCtx *log.Entry, archiveFileName, archiveCache, executableCache, extractFilepath, executableChecksum string, extractFileFromArchive func(archiveFileName, archivePath, extractFilepath, extractToDir string) error) error {
-	// TODO: do nothing if executable file already exists in cache
+func extractFile(fs afero.Fs, logCtx *log.Entry, archiveFileName, archiveCache, executableCache, extractFilepath, executableChecksum string, extractFileFromArchive func(archiveFileName, archivePath, extractFilepath, extractToDir string) error, lockFile func(path string, logCtx *log.Entry) (func(), error)) error {
+	unlock, err := LockCachePath(logCtx, executableCache, lockFile)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	// another dependency or lockal process may have extracted the same executable while waiting for the lock
+	executableCached, err := validateCacheEntry(fs, logCtx, executableCache, executableChecksum)
+	if err != nil {
+		return err
+	}
+
+	if executableCached {
+		logCtx.Debug(fmt.Sprintf("%s already exists in cache", executableCache))
+
+		return nil
+	}
 
 	tempDir, err := afero.TempDir(fs, "", "")
 	if err != nil {
 		return err
 	}
+	defer fs.RemoveAll(tempDir)
 
 	logCtx.Info(fmt.Sprintf("extracting %s from %s to %s", extractFilepath, archiveCache, fmt.Sprintf("%s/%s", tempDir, extractFilepath)))
 
@@ -70,16 +204,14 @@ func extractFile(fs afero.Fs, logCtx *log.Entry, archiveFileName, archiveCache,
 		return err
 	}
 
-	if err := copyFile(fs, logCtx, fmt.Sprintf("%s/%s", tempDir, extractFilepath), executableCache); err != nil {
-		return err
-	}
+	extractedFile := fmt.Sprintf("%s/%s", tempDir, extractFilepath)
 
-	removed, err := removeInvalidFile(fs, logCtx, executableCache, executableChecksum)
+	valid, err := writeVerifiedFile(fs, logCtx, executableCache, executableChecksum, 0644, streamFrom(fs, extractedFile))
 	if err != nil {
 		return err
 	}
 
-	if removed {
+	if !valid {
 		errorMessage := fmt.Sprintf("extracted %s did not match expected checksum", extractFilepath)
 		logCtx.Error(errorMessage)
 
@@ -2,6 +2,8 @@ package dependency
 
 import (
 	"fmt"
+	"io"
+	"os"
 	"testing"
 
 	"github.com/spf13/afero"
@@ -21,7 +23,7 @@ func TestExecutableFromArchiveDownload(t *testing.T) {
 		ExecutableChecksum: "bc07ffe5b4dbd2c52c87bce5298893c63e38a0d0333e2e01bbcfeddfdd40602724400d2998cb2a75e216aaffc913306a908d6057729a76102086b19556dc8be2",
 	}
 
-	getFile := func(dest, src string) error {
+	getFile := func(dest, src string, hash io.Writer) error {
 		if src == "http://archive.tgz?archive=false" {
 			return afero.WriteFile(fs, dest, []byte("an archive"), 0644)
 		}
@@ -56,7 +58,7 @@ func TestExecutableFromArchiveDownload(t *testing.T) {
 		t.Fatalf("unexpected error when invoking Download: %v", err)
 	}
 
-	getFileShouldNotBeCalled := func(dest, src string) error {
+	getFileShouldNotBeCalled := func(dest, src string, hash io.Writer) error {
 		return fmt.Errorf("getFile should not be called when archive exists in cache")
 	}
 
@@ -71,3 +73,88 @@ func TestExecutableFromArchiveDownload(t *testing.T) {
 		t.Fatalf("unexpected error when invoking Download after cache populated: %v", err)
 	}
 }
+
+func TestExecutableFromArchiveDownloadSkipsArchiveWhenExecutableIsCached(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	_, logCtx := getLogCtx()
+
+	efa := ExecutableFromArchive{
+		Name:               "exe",
+		Location:           "http://archive.tgz",
+		ArchiveChecksum:    "21b9c6c34401c466769ec75e894d47f3d5eb656358ae836dc6d87b7747af69377f8266913427dfcd0027e68873ae8962f8afd943a29ccfacacabd27113a981be",
+		ExtractFilepath:    "artifacts/executable",
+		ExecutableChecksum: "bc07ffe5b4dbd2c52c87bce5298893c63e38a0d0333e2e01bbcfeddfdd40602724400d2998cb2a75e216aaffc913306a908d6057729a76102086b19556dc8be2",
+	}
+
+	extractToDirs := []string{}
+
+	cfg := config.Config{
+		CacheDir: "/.cache",
+		Fs:       fs,
+		LogCtx:   logCtx,
+		GetFile: func(dest, src string, hash io.Writer) error {
+			return afero.WriteFile(fs, dest, []byte("an archive"), 0644)
+		},
+		ExtractFileFromArchive: func(archiveFileName, archivePath, extractFilepath, extractToDir string) error {
+			extractToDirs = append(extractToDirs, extractToDir)
+
+			return afero.WriteFile(fs, fmt.Sprintf("%s/%s", extractToDir, extractFilepath), []byte("an executable"), 0644)
+		},
+	}
+
+	if err := efa.Download(cfg); err != nil {
+		t.Fatalf("unexpected error when invoking Download: %v", err)
+	}
+
+	if len(extractToDirs) != 1 {
+		t.Fatalf("expected archive to be extracted once, but got %d", len(extractToDirs))
+	}
+
+	if _, err := fs.Stat(extractToDirs[0]); !os.IsNotExist(err) {
+		t.Errorf("expected temporary extraction directory to be removed, but got %v", err)
+	}
+
+	// only the extracted executable remains cached, so neither the archive nor extraction are needed
+	archiveCache, err := getCachePath(cfg.CacheDir, efa.ArchiveChecksum)
+	if err != nil {
+		t.Fatalf("unexpected error when invoking getCachePath: %v", err)
+	}
+
+	if err = fs.Remove(archiveCache); err != nil {
+		t.Fatalf("unexpected error removing cached archive: %v", err)
+	}
+
+	if err := fs.Remove("exe"); err != nil {
+		t.Fatalf("unexpected error removing exe: %v", err)
+	}
+
+	cfg.GetFile = func(dest, src string, hash io.Writer) error {
+		return fmt.Errorf("getFile should not be called when extracted file exists in cache")
+	}
+
+	cfg.ExtractFileFromArchive = func(archiveFileName, archivePath, extractFilepath, extractToDir string) error {
+		return fmt.Errorf("extractFileFromArchive should not be called when extracted file exists in cache")
+	}
+
+	plan, err := efa.Plan(cfg)
+	if err != nil {
+		t.Fatalf("unexpected error when invoking Plan: %v", err)
+	}
+
+	if plan.Action != ActionCopyFromCache {
+		t.Errorf("expected plan to copy from cache, but got %s", plan.Action)
+	}
+
+	if err = efa.Download(cfg); err != nil {
+		t.Fatalf("unexpected error when invoking Download with executable cached: %v", err)
+	}
+
+	status, err := efa.Verify(cfg)
+	if err != nil {
+		t.Fatalf("unexpected error when invoking Verify: %v", err)
+	}
+
+	if status != StatusOK {
+		t.Errorf("expected exe to be installed, but got %s", status)
+	}
+}
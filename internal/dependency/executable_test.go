@@ -2,7 +2,10 @@ package dependency
 
 import (
 	"fmt"
+	"io"
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/apex/log"
@@ -16,7 +19,7 @@ func TestDownload(t *testing.T) {
 	fs := afero.NewMemMapFs()
 	logHandler, logCtx := getLogCtx()
 
-	getFile := func(dest, src string) error {
+	getFile := func(dest, src string, hash io.Writer) error {
 		if src != "some.sh/ghosthouse" {
 			return fmt.Errorf("invalid src provided")
 		}
@@ -60,7 +63,7 @@ func TestDownload(t *testing.T) {
 		t.Fatalf("unexpected error while removing bin/ghostdog: %v", err)
 	}
 
-	getFileNoDownload := func(dest, src string) error {
+	getFileNoDownload := func(dest, src string, hash io.Writer) error {
 		return fmt.Errorf("getFileNoDownload should not have been called - cache should have been used")
 	}
 
@@ -106,7 +109,7 @@ func TestDownloadSkipsGettingFileIfAlreadyExistsWithSameChecksum(t *testing.T) {
 		t.Fatalf("unexpected error creating bin/dustin: %v", err)
 	}
 
-	getFile := func(dest, src string) error {
+	getFile := func(dest, src string, hash io.Writer) error {
 		t.Error("getFile should not have been called")
 
 		return fmt.Errorf("should not be called")
@@ -147,7 +150,7 @@ func TestDownloadUpdatesExecutableIfChecksumMismatch(t *testing.T) {
 		t.Fatalf("unexpected error creating bin/dustin: %v", err)
 	}
 
-	getFile := func(dest, src string) error {
+	getFile := func(dest, src string, hash io.Writer) error {
 		return afero.WriteFile(fs, dest, []byte("file dustin"), 0644)
 	}
 
@@ -182,14 +185,14 @@ func TestDownloadReturnsErrorIfChecksumDoesNotMatchAfterDownload(t *testing.T) {
 		t.Fatalf("unexpected error creating bin directory: %v", err)
 	}
 
-	getFile := func(dest, src string) error {
+	getFile := func(dest, src string, hash io.Writer) error {
 		return afero.WriteFile(fs, dest, []byte("file a"), 0644)
 	}
 
 	exe := Executable{
 		Name:     "ghostdog",
 		Location: "some.sh/ghosthouse",
-		Checksum: "hey",
+		Checksum: "81062acff49e1783200261a41491694aeefda5e2e0c8394dc16e6e620c4a951cb9b4daebcc2464b5ab8ab46af5046efd083e99e66df2fe69066019950f49c9b0",
 	}
 
 	cfg := config.Config{
@@ -204,7 +207,7 @@ func TestDownloadReturnsErrorIfChecksumDoesNotMatchAfterDownload(t *testing.T) {
 		t.Fatal("expected an error when checksums do not match")
 	}
 
-	expectedErrorMessage := "downloaded /var/lib/lockal/.cache/lockal/sha512/he/hey did not match expected checksum"
+	expectedErrorMessage := "downloaded /var/lib/lockal/.cache/lockal/sha512/81/81062acff49e1783200261a41491694aeefda5e2e0c8394dc16e6e620c4a951cb9b4daebcc2464b5ab8ab46af5046efd083e99e66df2fe69066019950f49c9b0 did not match expected checksum"
 
 	if err.Error() != expectedErrorMessage {
 		t.Errorf("expected error message of \"%s\", but got \"%s\"", expectedErrorMessage, err.Error())
@@ -213,7 +216,7 @@ func TestDownloadReturnsErrorIfChecksumDoesNotMatchAfterDownload(t *testing.T) {
 	if !hasLogEntry(logHandler, log.ErrorLevel, log.Fields{"app": "lockal-test"}, expectedErrorMessage) {
 		t.Error("expected a log message saying checksums did not match after download")
 	}
-	if !hasLogEntry(logHandler, log.InfoLevel, log.Fields{"app": "lockal-test"}, "removing /var/lib/lockal/.cache/lockal/sha512/he/hey since it has a checksum of a705aaf587ddc9ed135d4c318c339f3a0d6eb3a2e11936942afbfcd65254da6a1600b7b8e27f59464219fdc704f3b96c9953d80c05632411f475eea6f4548963, which does not match expected checksum of hey") {
+	if !hasLogEntry(logHandler, log.InfoLevel, log.Fields{"app": "lockal-test"}, "discarding the new content for /var/lib/lockal/.cache/lockal/sha512/81/81062acff49e1783200261a41491694aeefda5e2e0c8394dc16e6e620c4a951cb9b4daebcc2464b5ab8ab46af5046efd083e99e66df2fe69066019950f49c9b0 since it has a checksum of a705aaf587ddc9ed135d4c318c339f3a0d6eb3a2e11936942afbfcd65254da6a1600b7b8e27f59464219fdc704f3b96c9953d80c05632411f475eea6f4548963, which does not match expected checksum of 81062acff49e1783200261a41491694aeefda5e2e0c8394dc16e6e620c4a951cb9b4daebcc2464b5ab8ab46af5046efd083e99e66df2fe69066019950f49c9b0") {
 		t.Error("expected a log message saying checksums did not match after download")
 	}
 
@@ -229,14 +232,14 @@ func TestDownloadReturnsErrorIfChecksumDoesNotMatchAfterDownload(t *testing.T) {
 func TestDownloadReturnsErrorWhenGetFileErrs(t *testing.T) {
 	_, logCtx := getLogCtx()
 
-	getFile := func(dest, src string) error {
+	getFile := func(dest, src string, hash io.Writer) error {
 		return fmt.Errorf("some error")
 	}
 
 	exe := Executable{
 		Name:     "lockal",
 		Location: "some.sh/lockal",
-		Checksum: "samplechecksum",
+		Checksum: "81062acff49e1783200261a41491694aeefda5e2e0c8394dc16e6e620c4a951cb9b4daebcc2464b5ab8ab46af5046efd083e99e66df2fe69066019950f49c9b0",
 	}
 
 	cfg := config.Config{
@@ -255,3 +258,304 @@ func TestDownloadReturnsErrorWhenGetFileErrs(t *testing.T) {
 		t.Errorf("expected error message of \"some error\" to be returned, but got \"%s\"", err.Error())
 	}
 }
+
+func TestDownloadRefusesSymlinkedDownload(t *testing.T) {
+	dir := t.TempDir()
+	fs := afero.NewOsFs()
+	_, logCtx := getLogCtx()
+
+	source := filepath.Join(dir, "tool.sh")
+	if err := afero.WriteFile(fs, source, []byte("file a"), 0755); err != nil {
+		t.Fatalf("unexpected error writing %s: %v", source, err)
+	}
+
+	getFile := func(dest, src string, hash io.Writer) error {
+		if err := os.Remove(dest); err != nil {
+			return err
+		}
+
+		return os.Symlink(src, dest)
+	}
+
+	exe := Executable{
+		Name:     filepath.Join(dir, "bin", "tool"),
+		Location: source,
+		Checksum: "a705aaf587ddc9ed135d4c318c339f3a0d6eb3a2e11936942afbfcd65254da6a1600b7b8e27f59464219fdc704f3b96c9953d80c05632411f475eea6f4548963",
+	}
+
+	cfg := config.Config{
+		CacheDir: filepath.Join(dir, "cache"),
+		Fs:       fs,
+		LogCtx:   logCtx,
+		GetFile:  getFile,
+	}
+
+	if err := exe.Download(cfg); err == nil {
+		t.Fatal("expected an error when the download is a symbolic link")
+	}
+
+	stat, err := fs.Stat(source)
+	if err != nil {
+		t.Fatalf("unexpected error stating %s: %v", source, err)
+	}
+
+	if stat.Mode() != 0755 {
+		t.Errorf("expected %s to still be marked 0755, but was %v", source, stat.Mode())
+	}
+
+	cachePath := filepath.Join(dir, "cache", "lockal", "sha512", "a7", "a705aaf587ddc9ed135d4c318c339f3a0d6eb3a2e11936942afbfcd65254da6a1600b7b8e27f59464219fdc704f3b96c9953d80c05632411f475eea6f4548963")
+	if _, err := os.Lstat(cachePath); !os.IsNotExist(err) {
+		t.Errorf("expected %s to not be cached, but got %v", cachePath, err)
+	}
+}
+
+func TestDownloadDoesNotLeavePartialFileWhenGetFileErrs(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	_, logCtx := getLogCtx()
+
+	getFile := func(dest, src string, hash io.Writer) error {
+		if err := afero.WriteFile(fs, dest, []byte("partial fi"), 0644); err != nil {
+			return err
+		}
+
+		return fmt.Errorf("connection reset")
+	}
+
+	exe := Executable{
+		Name:     "bin/ghostdog",
+		Location: "some.sh/ghosthouse",
+		Checksum: "a705aaf587ddc9ed135d4c318c339f3a0d6eb3a2e11936942afbfcd65254da6a1600b7b8e27f59464219fdc704f3b96c9953d80c05632411f475eea6f4548963",
+	}
+
+	cfg := config.Config{
+		CacheDir: "/.cache",
+		Fs:       fs,
+		LogCtx:   logCtx,
+		GetFile:  getFile,
+	}
+
+	if err := exe.Download(cfg); err == nil {
+		t.Fatal("expected an error when getFile errs")
+	}
+
+	cacheEntries, err := afero.ReadDir(fs, "/.cache/lockal/sha512/a7")
+	if err != nil {
+		t.Fatalf("unexpected error reading cache directory: %v", err)
+	}
+
+	if len(cacheEntries) != 0 {
+		t.Errorf("expected cache directory to be empty, but found %s", cacheEntries[0].Name())
+	}
+}
+
+func TestDownloadReplacesCorruptCacheEntry(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	_, logCtx := getLogCtx()
+
+	cachePath := "/.cache/lockal/sha512/a7/a705aaf587ddc9ed135d4c318c339f3a0d6eb3a2e11936942afbfcd65254da6a1600b7b8e27f59464219fdc704f3b96c9953d80c05632411f475eea6f4548963"
+	if err := afero.WriteFile(fs, cachePath, []byte("corrupt"), 0644); err != nil {
+		t.Fatalf("unexpected error creating cache entry: %v", err)
+	}
+
+	exe := Executable{
+		Name:     "bin/ghostdog",
+		Location: "some.sh/ghosthouse",
+		Checksum: "a705aaf587ddc9ed135d4c318c339f3a0d6eb3a2e11936942afbfcd65254da6a1600b7b8e27f59464219fdc704f3b96c9953d80c05632411f475eea6f4548963",
+	}
+
+	getFileCalled := false
+
+	cfg := config.Config{
+		CacheDir: "/.cache",
+		Fs:       fs,
+		LogCtx:   logCtx,
+		GetFile: func(dest, src string, hash io.Writer) error {
+			getFileCalled = true
+
+			return afero.WriteFile(fs, dest, []byte("file a"), 0644)
+		},
+	}
+
+	plan, err := exe.Plan(cfg)
+	if err != nil {
+		t.Fatalf("unexpected error when invoking Plan: %v", err)
+	}
+
+	if plan.Action != ActionDownload {
+		t.Errorf("expected plan to download since cache entry is corrupt, but got %s", plan.Action)
+	}
+
+	if err = exe.Download(cfg); err != nil {
+		t.Fatalf("unexpected error when invoking Download: %v", err)
+	}
+
+	if !getFileCalled {
+		t.Error("expected corrupt cache entry to be downloaded again")
+	}
+
+	for _, filepath := range []string{cachePath, "bin/ghostdog"} {
+		content, err := afero.ReadFile(fs, filepath)
+		if err != nil {
+			t.Fatalf("unexpected error reading %s: %v", filepath, err)
+		}
+
+		if string(content) != "file a" {
+			t.Errorf("expected %s to contain file a, but got %s", filepath, string(content))
+		}
+	}
+}
+
+func TestDownloadAcceptsHashStreamedByGetFile(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	_, logCtx := getLogCtx()
+
+	getFile := func(dest, src string, hash io.Writer) error {
+		file, err := fs.Create(dest)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+
+		_, err = io.Copy(io.MultiWriter(file, hash), strings.NewReader("file a"))
+
+		return err
+	}
+
+	exe := Executable{
+		Name:     "bin/ghostdog",
+		Location: "some.sh/ghosthouse",
+		Checksum: "a705aaf587ddc9ed135d4c318c339f3a0d6eb3a2e11936942afbfcd65254da6a1600b7b8e27f59464219fdc704f3b96c9953d80c05632411f475eea6f4548963",
+	}
+
+	cfg := config.Config{
+		CacheDir: "/.cache",
+		Fs:       fs,
+		LogCtx:   logCtx,
+		GetFile:  getFile,
+	}
+
+	if err := exe.Download(cfg); err != nil {
+		t.Fatalf("expected no error, but got %v", err)
+	}
+
+	content, err := afero.ReadFile(fs, "bin/ghostdog")
+	if err != nil {
+		t.Fatalf("unexpected error reading bin/ghostdog: %v", err)
+	}
+
+	if string(content) != "file a" {
+		t.Errorf("expected bin/ghostdog to contain \"file a\", but got \"%s\"", string(content))
+	}
+}
+
+func TestDownloadLocksCacheEntryWhileWriting(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	_, logCtx := getLogCtx()
+
+	exe := Executable{
+		Name:     "bin/ghostdog",
+		Location: "some.sh/ghosthouse",
+		Checksum: "a705aaf587ddc9ed135d4c318c339f3a0d6eb3a2e11936942afbfcd65254da6a1600b7b8e27f59464219fdc704f3b96c9953d80c05632411f475eea6f4548963",
+	}
+
+	cachePath := "/.cache/lockal/sha512/a7/a705aaf587ddc9ed135d4c318c339f3a0d6eb3a2e11936942afbfcd65254da6a1600b7b8e27f59464219fdc704f3b96c9953d80c05632411f475eea6f4548963"
+	locked := false
+
+	cfg := config.Config{
+		CacheDir: "/.cache",
+		Fs:       fs,
+		LogCtx:   logCtx,
+		GetFile: func(dest, src string, hash io.Writer) error {
+			if !locked {
+				t.Error("expected cache entry to be locked while downloading")
+			}
+
+			return afero.WriteFile(fs, dest, []byte("file a"), 0644)
+		},
+		LockFile: func(path string, logCtx *log.Entry) (func(), error) {
+			if path != cachePath+".lock" {
+				t.Errorf("expected %s.lock to be locked, but got %s", cachePath, path)
+			}
+
+			locked = true
+
+			return func() { locked = false }, nil
+		},
+	}
+
+	if err := exe.Download(cfg); err != nil {
+		t.Fatalf("unexpected error when invoking Download: %v", err)
+	}
+
+	if locked {
+		t.Error("expected cache entry to be unlocked after downloading")
+	}
+}
+
+func TestDownloadWithPrefixedChecksum(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	logHandler, logCtx := getLogCtx()
+
+	exe := Executable{
+		Name:     "bin/ghostdog",
+		Location: "some.sh/ghosthouse",
+		Checksum: "sha256:63c5cdfc617fb8fe93888e68674717590957a25b5e08219b1a61c3f031f88ee6",
+	}
+
+	cfg := config.Config{
+		CacheDir: "/.cache",
+		Fs:       fs,
+		LogCtx:   logCtx,
+		GetFile: func(dest, src string, hash io.Writer) error {
+			return afero.WriteFile(fs, dest, []byte("file a"), 0644)
+		},
+	}
+
+	if err := exe.Download(cfg); err != nil {
+		t.Fatalf("unexpected error when invoking Download: %v", err)
+	}
+
+	if !hasLogEntry(logHandler, log.InfoLevel, log.Fields{"app": "lockal-test"}, "downloading some.sh/ghosthouse to /.cache/lockal/sha256/63/63c5cdfc617fb8fe93888e68674717590957a25b5e08219b1a61c3f031f88ee6") {
+		t.Error("expected file to be cached by its sha256")
+	}
+
+	status, err := exe.Verify(cfg)
+	if err != nil {
+		t.Fatalf("unexpected error when invoking Verify: %v", err)
+	}
+
+	if status != StatusOK {
+		t.Errorf("expected bin/ghostdog to match its sha256, but got %s", status)
+	}
+}
+
+func TestDownloadReturnsErrorForUnsupportedChecksumAlgorithm(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	_, logCtx := getLogCtx()
+
+	exe := Executable{
+		Name:     "bin/ghostdog",
+		Location: "some.sh/ghosthouse",
+		Checksum: "md5:3b5d3c7d207e37dceeedd301e35e2e58",
+	}
+
+	cfg := config.Config{
+		CacheDir: "/.cache",
+		Fs:       fs,
+		LogCtx:   logCtx,
+		GetFile: func(dest, src string, hash io.Writer) error {
+			t.Error("getFile should not have been called")
+
+			return fmt.Errorf("should not be called")
+		},
+	}
+
+	err := exe.Download(cfg)
+	if err == nil {
+		t.Fatal("expected an error for an unsupported checksum algorithm")
+	}
+
+	if !strings.HasPrefix(err.Error(), "unsupported checksum algorithm md5") {
+		t.Errorf("unexpected error message: %s", err.Error())
+	}
+}
@@ -0,0 +1,84 @@
+package dependency
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/dustinspecker/lockal/internal/config"
+	"github.com/dustinspecker/lockal/internal/digest"
+)
+
+// GetRemoteCacheLocation returns where the file with checksum is stored in the remote cache at remoteCache, keyed by
+// the checksum's algorithm such as URL/sha256/CHECKSUM
+func GetRemoteCacheLocation(remoteCache, checksum string) (string, error) {
+	algorithm, value, err := digest.Parse(checksum)
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%s/%s/%s", strings.TrimSuffix(remoteCache, "/"), algorithm, value), nil
+}
+
+// downloadFromRemoteCache attempts to download the file with expectedChecksum from the remote cache to dest. false is
+// returned when there's no remote cache or it doesn't have a valid copy of the file, so it can be downloaded from its
+// original location instead.
+func downloadFromRemoteCache(cfg config.Config, dest, expectedChecksum string) bool {
+	if cfg.RemoteCache == "" {
+		return false
+	}
+
+	location, err := GetRemoteCacheLocation(cfg.RemoteCache, expectedChecksum)
+	if err != nil {
+		cfg.LogCtx.WithError(err).Info("unable to download from remote cache")
+
+		return false
+	}
+
+	cfg.LogCtx.Info(fmt.Sprintf("downloading %s to %s", location, dest))
+
+	valid, err := writeVerifiedFile(cfg.Fs, cfg.LogCtx, dest, expectedChecksum, 0644, downloadTo(cfg.Fs, location, cfg.GetFile))
+	if err != nil {
+		cfg.LogCtx.WithError(err).Info(fmt.Sprintf("unable to download %s from remote cache", location))
+
+		return false
+	}
+
+	if !valid {
+		cfg.LogCtx.Warn(fmt.Sprintf("remote cache's %s did not match expected checksum", location))
+	}
+
+	return valid
+}
+
+// uploadToRemoteCache uploads the file at cachePath to the remote cache when enabled. Failing to upload is logged
+// rather than returned since the file was still installed.
+func uploadToRemoteCache(cfg config.Config, cachePath, checksum string) {
+	if cfg.RemoteCache == "" || !cfg.RemoteCacheUpload {
+		return
+	}
+
+	err := func() error {
+		location, err := GetRemoteCacheLocation(cfg.RemoteCache, checksum)
+		if err != nil {
+			return err
+		}
+
+		file, err := cfg.Fs.Open(cachePath)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+
+		stat, err := file.Stat()
+		if err != nil {
+			return err
+		}
+
+		cfg.LogCtx.Info(fmt.Sprintf("uploading %s to %s", cachePath, location))
+
+		return cfg.PutFile(location, file, stat.Size())
+	}()
+	if err != nil {
+		cfg.LogCtx.WithError(err).Warn(fmt.Sprintf("unable to upload %s to remote cache", cachePath))
+	}
+}
@@ -0,0 +1,110 @@
+package dependency
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"testing"
+
+	"github.com/spf13/afero"
+
+	"github.com/dustinspecker/lockal/internal/config"
+)
+
+func TestDownloadUsesRemoteCache(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	_, logCtx := getLogCtx()
+
+	exe := Executable{
+		Name:     "bin/ghostdog",
+		Location: "some.sh/ghosthouse",
+		Checksum: "a705aaf587ddc9ed135d4c318c339f3a0d6eb3a2e11936942afbfcd65254da6a1600b7b8e27f59464219fdc704f3b96c9953d80c05632411f475eea6f4548963",
+	}
+
+	cfg := config.Config{
+		CacheDir:    "/.cache",
+		Fs:          fs,
+		LogCtx:      logCtx,
+		RemoteCache: "https://cache.example.com/lockal/",
+		GetFile: func(dest, src string, hash io.Writer) error {
+			if src != "https://cache.example.com/lockal/sha512/"+exe.Checksum {
+				return fmt.Errorf("expected file to be downloaded from remote cache, but got %s", src)
+			}
+
+			return afero.WriteFile(fs, dest, []byte("file a"), 0644)
+		},
+	}
+
+	if err := exe.Download(cfg); err != nil {
+		t.Fatalf("unexpected error when invoking Download: %v", err)
+	}
+
+	content, err := afero.ReadFile(fs, "bin/ghostdog")
+	if err != nil {
+		t.Fatalf("unexpected error reading bin/ghostdog: %v", err)
+	}
+
+	if string(content) != "file a" {
+		t.Errorf("expected bin/ghostdog to contain file a, but got %s", string(content))
+	}
+}
+
+func TestDownloadFallsBackToLocationAndUploadsToRemoteCache(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	_, logCtx := getLogCtx()
+
+	exe := Executable{
+		Name:     "bin/ghostdog",
+		Location: "some.sh/ghosthouse",
+		Checksum: "a705aaf587ddc9ed135d4c318c339f3a0d6eb3a2e11936942afbfcd65254da6a1600b7b8e27f59464219fdc704f3b96c9953d80c05632411f475eea6f4548963",
+	}
+
+	uploaded := map[string]string{}
+
+	cfg := config.Config{
+		CacheDir:          "/.cache",
+		Fs:                fs,
+		LogCtx:            logCtx,
+		RemoteCache:       "https://cache.example.com",
+		RemoteCacheUpload: true,
+		GetFile: func(dest, src string, hash io.Writer) error {
+			if src == "some.sh/ghosthouse" {
+				return afero.WriteFile(fs, dest, []byte("file a"), 0644)
+			}
+
+			// a remote cache that served the wrong content must not be trusted
+			return afero.WriteFile(fs, dest, []byte("not file a"), 0644)
+		},
+		PutFile: func(dest string, content io.Reader, size int64) error {
+			uploadedContent, err := ioutil.ReadAll(content)
+			if err != nil {
+				return err
+			}
+
+			if int64(len(uploadedContent)) != size {
+				t.Errorf("expected size of %d, but got %d", len(uploadedContent), size)
+			}
+
+			uploaded[dest] = string(uploadedContent)
+
+			return nil
+		},
+	}
+
+	if err := exe.Download(cfg); err != nil {
+		t.Fatalf("unexpected error when invoking Download: %v", err)
+	}
+
+	if uploaded["https://cache.example.com/sha512/"+exe.Checksum] != "file a" {
+		t.Errorf("expected file a to be uploaded to remote cache, but got %+v", uploaded)
+	}
+
+	status, err := exe.Verify(cfg)
+	if err != nil {
+		t.Fatalf("unexpected error when invoking Verify: %v", err)
+	}
+
+	if status != StatusOK {
+		t.Errorf("expected bin/ghostdog to be installed, but got %s", status)
+	}
+}
@@ -0,0 +1,154 @@
+package dependency
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/afero"
+
+	"github.com/dustinspecker/lockal/internal/config"
+	"github.com/dustinspecker/lockal/internal/digest"
+	"github.com/dustinspecker/lockal/internal/signature"
+)
+
+// TrustedKeysFilename is the file next to lockal.star that names public keys for rules to verify signatures with
+const TrustedKeysFilename = "lockal.keys"
+
+// TrustedKeys is the content of TrustedKeysFilename
+type TrustedKeys struct {
+	Keys map[string]string `json:"keys"`
+}
+
+// Signature is a detached signature of a dependency's downloaded file made with a pinned public key
+type Signature struct {
+	// Location to download the signature from
+	Location string
+	// Key is the public key, see signature.Verify for supported keys
+	Key string
+	// KeyName is the name of a key in TrustedKeysFilename to use instead of Key
+	KeyName string
+}
+
+// IsSet returns whether the dependency's downloaded file must be signed
+func (sig Signature) IsSet() bool {
+	return sig.Location != ""
+}
+
+// GetSignaturePath returns where the verified signature of the cache entry at cachePath is cached
+func GetSignaturePath(cachePath string) string {
+	return fmt.Sprintf("%s.sig", cachePath)
+}
+
+// cache returns where the signature of the cache entry at cachePath is cached, if the dependency requires one
+func (sig Signature) cache(cachePath string) []string {
+	if !sig.IsSet() {
+		return []string{}
+	}
+
+	return []string{GetSignaturePath(cachePath)}
+}
+
+// isCached returns whether verifying the cache entry at cachePath can be done without downloading anything
+func (sig Signature) isCached(fs afero.Fs, cachePath string) (bool, error) {
+	if !sig.IsSet() {
+		return true, nil
+	}
+
+	_, err := fs.Stat(GetSignaturePath(cachePath))
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+
+	return err == nil, err
+}
+
+// getKey returns Key or looks up KeyName in the TrustedKeysFilename of project
+func (sig Signature) getKey(fs afero.Fs, project string) (string, error) {
+	if sig.KeyName == "" {
+		return sig.Key, nil
+	}
+
+	content, err := afero.ReadFile(fs, filepath.Join(project, TrustedKeysFilename))
+	if err != nil {
+		return "", fmt.Errorf("reading %s for key %s: %w", TrustedKeysFilename, sig.KeyName, err)
+	}
+
+	trustedKeys := TrustedKeys{}
+	if err = json.Unmarshal(content, &trustedKeys); err != nil {
+		return "", fmt.Errorf("parsing %s: %w", TrustedKeysFilename, err)
+	}
+
+	key, ok := trustedKeys.Keys[sig.KeyName]
+	if !ok {
+		return "", fmt.Errorf("no key named %s in %s", sig.KeyName, TrustedKeysFilename)
+	}
+
+	return key, nil
+}
+
+// verify returns an error unless the cache entry at cachePath is signed by sig's key. The signature is only downloaded
+// when it isn't already cached next to the cache entry, so verifying works offline afterwards.
+func (sig Signature) verify(cfg config.Config, cachePath string) error {
+	if !sig.IsSet() {
+		return nil
+	}
+
+	key, err := sig.getKey(cfg.Fs, cfg.Project)
+	if err != nil {
+		return err
+	}
+
+	signaturePath := GetSignaturePath(cachePath)
+
+	cachedSignature, err := afero.ReadFile(cfg.Fs, signaturePath)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	if err == nil {
+		err = verifySignature(cfg.Fs, key, cachedSignature, cachePath)
+		if err == nil {
+			cfg.LogCtx.Debug(fmt.Sprintf("verified signature of %s", cachePath))
+
+			return nil
+		}
+
+		cfg.LogCtx.WithError(err).Warn(fmt.Sprintf("replacing %s since it isn't a valid signature", signaturePath))
+	}
+
+	cfg.LogCtx.Info(fmt.Sprintf("downloading %s to %s", sig.Location, signaturePath))
+
+	tempPath, _, err := writeTempFile(cfg.Fs, filepath.Dir(cachePath), digest.Default, downloadTo(cfg.Fs, sig.Location, cfg.GetFile))
+	if err != nil {
+		return err
+	}
+	defer cfg.Fs.Remove(tempPath)
+
+	downloadedSignature, err := afero.ReadFile(cfg.Fs, tempPath)
+	if err != nil {
+		return err
+	}
+
+	if err = verifySignature(cfg.Fs, key, downloadedSignature, cachePath); err != nil {
+		errorMessage := fmt.Sprintf("%s is not a valid signature of %s: %v", sig.Location, cachePath, err)
+		cfg.LogCtx.Error(errorMessage)
+
+		return fmt.Errorf(errorMessage)
+	}
+
+	cfg.LogCtx.Debug(fmt.Sprintf("verified signature of %s", cachePath))
+
+	return cfg.Fs.Rename(tempPath, signaturePath)
+}
+
+func verifySignature(fs afero.Fs, key string, sig []byte, path string) error {
+	file, err := fs.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	return signature.Verify(key, sig, file)
+}
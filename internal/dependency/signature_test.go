@@ -0,0 +1,216 @@
+package dependency
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/spf13/afero"
+
+	"github.com/dustinspecker/lockal/internal/config"
+)
+
+// newSignify returns a signify public key and a signature of content made with it
+func newSignify(t *testing.T, content string) (string, string) {
+	t.Helper()
+
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("unexpected error generating key: %v", err)
+	}
+
+	keyID := []byte("lockal00")
+	key := append(append([]byte("Ed"), keyID...), publicKey...)
+	signature := append(append([]byte("Ed"), keyID...), ed25519.Sign(privateKey, []byte(content))...)
+
+	return base64.StdEncoding.EncodeToString(key), "untrusted comment: verify with lockal.pub\n" + base64.StdEncoding.EncodeToString(signature) + "\n"
+}
+
+func TestDownloadVerifiesSignature(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	_, logCtx := getLogCtx()
+
+	publicKey, signature := newSignify(t, "file a")
+
+	exe := Executable{
+		Name:      "bin/ghostdog",
+		Location:  "some.sh/ghosthouse",
+		Checksum:  "a705aaf587ddc9ed135d4c318c339f3a0d6eb3a2e11936942afbfcd65254da6a1600b7b8e27f59464219fdc704f3b96c9953d80c05632411f475eea6f4548963",
+		Signature: Signature{Location: "some.sh/ghosthouse.sig", Key: publicKey},
+	}
+
+	cfg := config.Config{
+		CacheDir: "/.cache",
+		Fs:       fs,
+		LogCtx:   logCtx,
+		GetFile: func(dest, src string, hash io.Writer) error {
+			if src == "some.sh/ghosthouse.sig" {
+				return afero.WriteFile(fs, dest, []byte(signature), 0644)
+			}
+
+			return afero.WriteFile(fs, dest, []byte("file a"), 0644)
+		},
+	}
+
+	if err := exe.Download(cfg); err != nil {
+		t.Fatalf("unexpected error when invoking Download: %v", err)
+	}
+
+	cache, err := getCachePath(cfg.CacheDir, exe.Checksum)
+	if err != nil {
+		t.Fatalf("unexpected error when invoking getCachePath: %v", err)
+	}
+
+	if _, err = fs.Stat(GetSignaturePath(cache)); err != nil {
+		t.Fatalf("expected signature to be cached, but got %v", err)
+	}
+
+	// the cached signature is verified again without downloading anything
+	cfg.GetFile = func(dest, src string, hash io.Writer) error {
+		return fmt.Errorf("unable to download %s while offline", src)
+	}
+
+	caches, err := exe.Cache(cfg)
+	if err != nil {
+		t.Fatalf("unexpected error when invoking Cache offline: %v", err)
+	}
+
+	if len(caches) != 2 || caches[1] != GetSignaturePath(cache) {
+		t.Errorf("expected cached signature to be returned, but got %v", caches)
+	}
+}
+
+func TestDownloadReturnsErrorForInvalidSignature(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	_, logCtx := getLogCtx()
+
+	publicKey, _ := newSignify(t, "file a")
+	_, otherSignature := newSignify(t, "file a")
+
+	if err := afero.WriteFile(fs, TrustedKeysFilename, []byte(`{"keys": {"ghost": "`+publicKey+`"}}`), 0644); err != nil {
+		t.Fatalf("unexpected error creating %s: %v", TrustedKeysFilename, err)
+	}
+
+	exe := Executable{
+		Name:      "bin/ghostdog",
+		Location:  "some.sh/ghosthouse",
+		Checksum:  "a705aaf587ddc9ed135d4c318c339f3a0d6eb3a2e11936942afbfcd65254da6a1600b7b8e27f59464219fdc704f3b96c9953d80c05632411f475eea6f4548963",
+		Signature: Signature{Location: "some.sh/ghosthouse.sig", KeyName: "ghost"},
+	}
+
+	cfg := config.Config{
+		CacheDir: "/.cache",
+		Fs:       fs,
+		LogCtx:   logCtx,
+		GetFile: func(dest, src string, hash io.Writer) error {
+			if src == "some.sh/ghosthouse.sig" {
+				return afero.WriteFile(fs, dest, []byte(otherSignature), 0644)
+			}
+
+			return afero.WriteFile(fs, dest, []byte("file a"), 0644)
+		},
+	}
+
+	err := exe.Download(cfg)
+	if err == nil {
+		t.Fatal("expected an error for a signature made with another key")
+	}
+
+	if !strings.HasPrefix(err.Error(), "some.sh/ghosthouse.sig is not a valid signature of /.cache/lockal/sha512/a7/") {
+		t.Errorf("unexpected error message: %s", err.Error())
+	}
+
+	if _, err = fs.Stat("bin/ghostdog"); !os.IsNotExist(err) {
+		t.Errorf("expected bin/ghostdog to not be installed, but got %v", err)
+	}
+}
+
+func TestSignatureReturnsErrorForUnknownKeyName(t *testing.T) {
+	fs := afero.NewMemMapFs()
+
+	if err := afero.WriteFile(fs, TrustedKeysFilename, []byte(`{"keys": {}}`), 0644); err != nil {
+		t.Fatalf("unexpected error creating %s: %v", TrustedKeysFilename, err)
+	}
+
+	_, err := Signature{Location: "some.sh/ghosthouse.sig", KeyName: "ghost"}.getKey(fs, "")
+	if err == nil {
+		t.Fatal("expected an error for an unknown key name")
+	}
+
+	if err.Error() != "no key named ghost in lockal.keys" {
+		t.Errorf("unexpected error message: %s", err.Error())
+	}
+}
+
+func TestExecutableFromArchiveDownloadVerifiesSignatureWhenExecutableIsCached(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	_, logCtx := getLogCtx()
+
+	publicKey, signature := newSignify(t, "an archive")
+	_, otherSignature := newSignify(t, "an archive")
+
+	efa := ExecutableFromArchive{
+		Name:               "exe",
+		Location:           "http://archive.tgz",
+		ArchiveChecksum:    "21b9c6c34401c466769ec75e894d47f3d5eb656358ae836dc6d87b7747af69377f8266913427dfcd0027e68873ae8962f8afd943a29ccfacacabd27113a981be",
+		ExtractFilepath:    "artifacts/executable",
+		ExecutableChecksum: "bc07ffe5b4dbd2c52c87bce5298893c63e38a0d0333e2e01bbcfeddfdd40602724400d2998cb2a75e216aaffc913306a908d6057729a76102086b19556dc8be2",
+		Signature:          Signature{Location: "http://archive.tgz.sig", Key: publicKey},
+	}
+
+	servedSignature := otherSignature
+
+	cfg := config.Config{
+		CacheDir: "/.cache",
+		Fs:       fs,
+		LogCtx:   logCtx,
+		GetFile: func(dest, src string, hash io.Writer) error {
+			if src == "http://archive.tgz.sig" {
+				return afero.WriteFile(fs, dest, []byte(servedSignature), 0644)
+			}
+
+			return afero.WriteFile(fs, dest, []byte("an archive"), 0644)
+		},
+		ExtractFileFromArchive: func(archiveFileName, archivePath, extractFilepath, extractToDir string) error {
+			return fmt.Errorf("extractFileFromArchive should not be called when extracted file exists in cache")
+		},
+	}
+
+	// another dependency already extracted the same executable into the shared cache
+	executableCache, err := getCachePath(cfg.CacheDir, efa.ExecutableChecksum)
+	if err != nil {
+		t.Fatalf("unexpected error when invoking getCachePath: %v", err)
+	}
+
+	if err = afero.WriteFile(fs, executableCache, []byte("an executable"), 0644); err != nil {
+		t.Fatalf("unexpected error caching executable: %v", err)
+	}
+
+	plan, err := efa.Plan(cfg)
+	if err != nil {
+		t.Fatalf("unexpected error when invoking Plan: %v", err)
+	}
+
+	if plan.Action != ActionDownload {
+		t.Errorf("expected archive to be downloaded to verify its signature, but got %s", plan.Action)
+	}
+
+	if err = efa.Download(cfg); err == nil {
+		t.Fatal("expected an error for a signature made with another key")
+	}
+
+	if _, err = fs.Stat("exe"); !os.IsNotExist(err) {
+		t.Errorf("expected exe to not be installed, but got %v", err)
+	}
+
+	servedSignature = signature
+
+	if err = efa.Download(cfg); err != nil {
+		t.Fatalf("unexpected error when invoking Download with a valid signature: %v", err)
+	}
+}
@@ -1,17 +1,68 @@
 package dependency
 
 import (
-	"crypto/sha512"
 	"fmt"
+	"hash"
 	"io"
 	"os"
 	"path/filepath"
+	"sync"
 
 	"github.com/apex/log"
 
 	"github.com/spf13/afero"
+
+	"github.com/dustinspecker/lockal/internal/config"
+	"github.com/dustinspecker/lockal/internal/digest"
 )
 
+// tempFilePrefix is used to name files that are still being written
+const tempFilePrefix = ".lockal-tmp-"
+
+// cacheLocks prevents dependencies being installed at the same time from writing to the same cache entry, while
+// lockFile is used to do the same across lockal processes sharing a cache
+var cacheLocks = struct {
+	sync.Mutex
+	paths map[string]*sync.Mutex
+}{
+	paths: map[string]*sync.Mutex{},
+}
+
+// LockCachePath blocks until no other dependency or lockal process is using the cache entry at cachePath, and returns a
+// function to release it
+func LockCachePath(logCtx *log.Entry, cachePath string, lockFile func(path string, logCtx *log.Entry) (func(), error)) (func(), error) {
+	cacheLocks.Lock()
+	pathLock, ok := cacheLocks.paths[cachePath]
+	if !ok {
+		pathLock = &sync.Mutex{}
+		cacheLocks.paths[cachePath] = pathLock
+	}
+	cacheLocks.Unlock()
+
+	pathLock.Lock()
+
+	if lockFile == nil {
+		return pathLock.Unlock, nil
+	}
+
+	unlockFile, err := lockFile(GetCacheLockPath(cachePath), logCtx)
+	if err != nil {
+		pathLock.Unlock()
+
+		return nil, err
+	}
+
+	return func() {
+		unlockFile()
+		pathLock.Unlock()
+	}, nil
+}
+
+// GetCacheLockPath returns the file locked by lockal processes using the cache entry at cachePath
+func GetCacheLockPath(cachePath string) string {
+	return fmt.Sprintf("%s.lock", cachePath)
+}
+
 func validateExistingFile(fs afero.Fs, logCtx *log.Entry, filepath, expectedChecksum string) (bool, error) {
 	_, err := fs.Stat(filepath)
 
@@ -36,63 +87,286 @@ func validateExistingFile(fs afero.Fs, logCtx *log.Entry, filepath, expectedChec
 	return false, nil
 }
 
-func downloadFile(fs afero.Fs, logCtx *log.Entry, location, dest, expectedChecksum string, getFile func(dest, src string) error) error {
-	_, err := fs.Stat(dest)
+// validateCacheEntry returns whether cachePath exists and matches expectedChecksum. A corrupt cache entry is removed so
+// it can be replaced instead of being installed.
+func validateCacheEntry(fs afero.Fs, logCtx *log.Entry, cachePath, expectedChecksum string) (bool, error) {
+	status, err := VerifyFile(fs, cachePath, expectedChecksum)
 	if err != nil {
-		if !os.IsNotExist(err) {
-			return err
+		return false, err
+	}
+
+	if status == StatusChecksumMismatch {
+		logCtx.Warn(fmt.Sprintf("removing %s from cache since it does not match expected checksum", cachePath))
+
+		if err = fs.Remove(cachePath); err != nil && !os.IsNotExist(err) {
+			return false, err
+		}
+	}
+
+	return status == StatusOK, nil
+}
+
+// isCached returns whether cachePath exists and matches expectedChecksum without modifying anything
+func isCached(fs afero.Fs, cachePath, expectedChecksum string) (bool, error) {
+	status, err := VerifyFile(fs, cachePath, expectedChecksum)
+
+	return status == StatusOK, err
+}
+
+// getCachePath returns where the file with checksum is cached, keyed by the checksum's algorithm
+func getCachePath(cacheDir, checksum string) (string, error) {
+	algorithm, value, err := digest.Parse(checksum)
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%s/lockal/%s/%s/%s", cacheDir, algorithm, value[0:2], value), nil
+}
+
+// VerifyFile compares the file at filepath to expectedChecksum without modifying it
+func VerifyFile(fs afero.Fs, filepath, expectedChecksum string) (Status, error) {
+	actualChecksum, err := getChecksum(fs, filepath, expectedChecksum)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return StatusMissing, nil
 		}
 
-		logCtx.Info(fmt.Sprintf("downloading %s to %s", location, dest))
+		return "", err
+	}
+
+	if !matches(actualChecksum, expectedChecksum) {
+		return StatusChecksumMismatch, nil
+	}
+
+	return StatusOK, nil
+}
+
+func downloadFile(cfg config.Config, location, dest, expectedChecksum string) error {
+	unlock, err := LockCachePath(cfg.LogCtx, dest, cfg.LockFile)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	cached, err := validateCacheEntry(cfg.Fs, cfg.LogCtx, dest, expectedChecksum)
+	if err != nil {
+		return err
+	}
+
+	if cached {
+		return nil
+	}
+
+	if downloadFromRemoteCache(cfg, dest, expectedChecksum) {
+		return nil
+	}
+
+	cfg.LogCtx.Info(fmt.Sprintf("downloading %s to %s", location, dest))
+
+	valid, err := writeVerifiedFile(cfg.Fs, cfg.LogCtx, dest, expectedChecksum, 0644, downloadTo(cfg.Fs, location, cfg.GetFile))
+	if err != nil {
+		return err
+	}
+
+	if !valid {
+		errorMessage := fmt.Sprintf("downloaded %s did not match expected checksum", dest)
+		cfg.LogCtx.Error(errorMessage)
+
+		return fmt.Errorf(errorMessage)
+	}
+
+	uploadToRemoteCache(cfg, dest, expectedChecksum)
+
+	return nil
+}
+
+// copyFromCache copies the cache entry at cachePath to dest while holding its lock, so lockal cache gc or lockal cache
+// verify can't remove the entry in the middle of copying
+func copyFromCache(cfg config.Config, cachePath, dest, expectedChecksum string) error {
+	unlock, err := LockCachePath(cfg.LogCtx, cachePath, cfg.LockFile)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	return copyFile(cfg.Fs, cfg.LogCtx, cachePath, dest, expectedChecksum, 0755)
+}
+
+func copyFile(fs afero.Fs, logCtx *log.Entry, src, dest, expectedChecksum string, perm os.FileMode) error {
+	logCtx.Info(fmt.Sprintf("copying from %s to %s", src, dest))
+
+	valid, err := writeVerifiedFile(fs, logCtx, dest, expectedChecksum, perm, streamFrom(fs, src))
+	if err != nil {
+		return err
+	}
+
+	if !valid {
+		errorMessage := fmt.Sprintf("copied %s did not match expected checksum", src)
+		logCtx.Error(errorMessage)
+
+		return fmt.Errorf(errorMessage)
+	}
+
+	return nil
+}
 
-		if err := getFile(dest, location); err != nil {
+// downloadTo returns a write function for writeVerifiedFile that uses getFile to download location
+func downloadTo(fs afero.Fs, location string, getFile func(dest, src string, hash io.Writer) error) func(tempFile afero.File, fileHash hash.Hash) error {
+	return func(tempFile afero.File, fileHash hash.Hash) error {
+		received := &countingWriter{writer: fileHash}
+		if err := getFile(tempFile.Name(), location, received); err != nil {
 			return err
 		}
 
-		removed, err := removeInvalidFile(fs, logCtx, dest, expectedChecksum)
+		stat, err := fs.Stat(tempFile.Name())
 		if err != nil {
 			return err
 		}
 
-		if removed {
-			errorMessage := fmt.Sprintf("downloaded %s did not match expected checksum", dest)
-			logCtx.Error(errorMessage)
+		if received.count == stat.Size() {
+			return nil
+		}
+
+		// getFile wasn't able to stream everything it downloaded to the hash, so hash the downloaded file instead
+		fileHash.Reset()
 
-			return fmt.Errorf(errorMessage)
+		downloaded, err := fs.Open(tempFile.Name())
+		if err != nil {
+			return err
 		}
-	}
+		defer downloaded.Close()
 
-	return nil
+		_, err = io.Copy(fileHash, downloaded)
+
+		return err
+	}
 }
 
-func copyFile(fs afero.Fs, logCtx *log.Entry, src, dest string) error {
-	logCtx.Info(fmt.Sprintf("copying from %s to %s", src, dest))
+// streamFrom returns a write function for writeVerifiedFile that copies src while hashing it, so src is only read once
+// and never held in memory
+func streamFrom(fs afero.Fs, src string) func(tempFile afero.File, fileHash hash.Hash) error {
+	return func(tempFile afero.File, fileHash hash.Hash) error {
+		srcFile, err := fs.Open(src)
+		if err != nil {
+			return err
+		}
+		defer srcFile.Close()
+
+		_, err = io.Copy(tempFile, io.TeeReader(srcFile, fileHash))
 
-	if err := fs.MkdirAll(filepath.Dir(dest), 0755); err != nil {
 		return err
 	}
+}
 
-	// copy from src to dest
-	cacheContent, err := afero.ReadFile(fs, src)
+// writeVerifiedFile has write fill a temporary file in the same directory as dest and write the same content to fileHash.
+// The temporary file is only renamed to dest once it has been synced to disk and matches expectedChecksum, so an
+// interrupted or invalid write never leaves a partial file at dest. false is returned if the written file did not
+// match expectedChecksum.
+func writeVerifiedFile(fs afero.Fs, logCtx *log.Entry, dest, expectedChecksum string, perm os.FileMode, write func(tempFile afero.File, fileHash hash.Hash) error) (bool, error) {
+	algorithm, _, err := digest.Parse(expectedChecksum)
 	if err != nil {
-		return err
+		return false, err
+	}
+
+	tempPath, actualChecksum, err := writeTempFile(fs, filepath.Dir(dest), algorithm, write)
+	if err != nil {
+		return false, err
+	}
+	defer fs.Remove(tempPath)
+
+	if !matches(actualChecksum, expectedChecksum) {
+		logCtx.Info(fmt.Sprintf("discarding the new content for %s since it has a checksum of %s, which does not match expected checksum of %s", dest, actualChecksum, expectedChecksum))
+
+		return false, nil
+	}
+
+	symlink, err := isSymlink(fs, tempPath)
+	if err != nil {
+		return false, err
+	}
+
+	if symlink {
+		return false, fmt.Errorf("refusing to write %s since its content is a symbolic link instead of a file", dest)
+	}
+
+	if err = fs.Chmod(tempPath, perm); err != nil {
+		return false, err
+	}
+
+	return true, fs.Rename(tempPath, dest)
+}
+
+// isSymlink returns true if path is a symbolic link, when fs is able to tell
+func isSymlink(fs afero.Fs, path string) (bool, error) {
+	lstater, ok := fs.(afero.Lstater)
+	if !ok {
+		return false, nil
+	}
+
+	info, _, err := lstater.LstatIfPossible(path)
+	if err != nil {
+		return false, err
 	}
 
-	return afero.WriteFile(fs, dest, cacheContent, 0755)
+	return info.Mode()&os.ModeSymlink != 0, nil
 }
 
-func markFileExecutable(fs afero.Fs, filepath string) error {
-	return fs.Chmod(filepath, 0755)
+// writeTempFile has write fill a temporary file in dir and write the same content to a hash for algorithm. The
+// temporary file is synced to disk before its path and checksum are returned. The caller is responsible for renaming or
+// removing it.
+func writeTempFile(fs afero.Fs, dir, algorithm string, write func(tempFile afero.File, fileHash hash.Hash) error) (string, string, error) {
+	fileHash, err := digest.New(algorithm)
+	if err != nil {
+		return "", "", err
+	}
+
+	if err = fs.MkdirAll(dir, 0755); err != nil {
+		return "", "", err
+	}
+
+	tempFile, err := afero.TempFile(fs, dir, tempFilePrefix)
+	if err != nil {
+		return "", "", err
+	}
+
+	err = write(tempFile, fileHash)
+	if err == nil {
+		err = tempFile.Sync()
+	}
+
+	if closeErr := tempFile.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err != nil {
+		fs.Remove(tempFile.Name())
+
+		return "", "", err
+	}
+
+	return tempFile.Name(), digest.Format(algorithm, fmt.Sprintf("%x", fileHash.Sum(nil))), nil
+}
+
+type countingWriter struct {
+	writer io.Writer
+	count  int64
+}
+
+func (cw *countingWriter) Write(p []byte) (int, error) {
+	n, err := cw.writer.Write(p)
+	cw.count += int64(n)
+
+	return n, err
 }
 
 func removeInvalidFile(fs afero.Fs, logCtx *log.Entry, targetPath, expectedChecksum string) (bool, error) {
-	actualChecksum, err := getChecksum(fs, targetPath)
+	actualChecksum, err := getChecksum(fs, targetPath, expectedChecksum)
 	if err != nil {
 		return false, err
 	}
 
 	// checksum matches, so don't remove file
-	if actualChecksum == expectedChecksum {
+	if matches(actualChecksum, expectedChecksum) {
 		return false, nil
 	}
 
@@ -105,18 +379,35 @@ func removeInvalidFile(fs afero.Fs, logCtx *log.Entry, targetPath, expectedCheck
 	return true, nil
 }
 
-func getChecksum(fs afero.Fs, filepath string) (string, error) {
+// getChecksum returns the checksum of the file at filepath using the same algorithm as expectedChecksum
+func getChecksum(fs afero.Fs, filepath, expectedChecksum string) (string, error) {
+	algorithm, _, err := digest.Parse(expectedChecksum)
+	if err != nil {
+		return "", err
+	}
+
+	fileHash, err := digest.New(algorithm)
+	if err != nil {
+		return "", err
+	}
+
 	fileContent, err := fs.Open(filepath)
 	if err != nil {
 		return "", err
 	}
 	defer fileContent.Close()
 
-	hash := sha512.New()
-
-	if _, err = io.Copy(hash, fileContent); err != nil {
-		return "", nil
+	if _, err = io.Copy(fileHash, fileContent); err != nil {
+		return "", err
 	}
 
-	return fmt.Sprintf("%x", hash.Sum(nil)), nil
+	return digest.Format(algorithm, fmt.Sprintf("%x", fileHash.Sum(nil))), nil
+}
+
+// matches returns whether actualChecksum, as returned by getChecksum, is the same as expectedChecksum, which may be
+// written with or without a sha512: prefix or in uppercase
+func matches(actualChecksum, expectedChecksum string) bool {
+	normalized, err := digest.Normalize(expectedChecksum)
+
+	return err == nil && actualChecksum == normalized
 }
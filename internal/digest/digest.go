@@ -0,0 +1,100 @@
+package digest
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"sort"
+	"strings"
+
+	"golang.org/x/crypto/blake2b"
+)
+
+// Default is the algorithm of checksums without a prefix, so checksums written before prefixes were supported keep
+// working
+const Default = "sha512"
+
+var algorithms = map[string]func() hash.Hash{
+	"blake2b": newBlake2b,
+	"sha256":  sha256.New,
+	"sha384":  sha512.New384,
+	"sha512":  sha512.New,
+}
+
+// Algorithms returns the supported algorithms in alphabetical order
+func Algorithms() []string {
+	names := []string{}
+	for name := range algorithms {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+// Parse returns the algorithm and lowercase hex value of checksum, such as sha256:VALUE. A checksum without a prefix
+// is a sha512.
+func Parse(checksum string) (string, string, error) {
+	algorithm, value := Default, checksum
+
+	if index := strings.Index(checksum, ":"); index != -1 {
+		algorithm, value = checksum[:index], checksum[index+1:]
+	}
+
+	if _, ok := algorithms[algorithm]; !ok {
+		return "", "", fmt.Errorf("unsupported checksum algorithm %s in %s, expected one of %s", algorithm, checksum, strings.Join(Algorithms(), ", "))
+	}
+
+	// the value is used in cache paths, so anything other than hex of the algorithm's length is rejected
+	if length := algorithms[algorithm]().Size() * 2; len(value) != length || !isHex(value) {
+		return "", "", fmt.Errorf("invalid checksum %s, expected %d hex characters for %s", checksum, length, algorithm)
+	}
+
+	return algorithm, strings.ToLower(value), nil
+}
+
+// Normalize returns checksum the same way Format does, so equal checksums written differently compare equal
+func Normalize(checksum string) (string, error) {
+	algorithm, value, err := Parse(checksum)
+	if err != nil {
+		return "", err
+	}
+
+	return Format(algorithm, value), nil
+}
+
+// Format returns the checksum for the hex value computed by algorithm. sha512 checksums aren't prefixed.
+func Format(algorithm, value string) string {
+	if algorithm == Default {
+		return value
+	}
+
+	return fmt.Sprintf("%s:%s", algorithm, value)
+}
+
+// New returns a hash computing checksums with algorithm
+func New(algorithm string) (hash.Hash, error) {
+	newHash, ok := algorithms[algorithm]
+	if !ok {
+		return nil, fmt.Errorf("unsupported checksum algorithm %s, expected one of %s", algorithm, strings.Join(Algorithms(), ", "))
+	}
+
+	return newHash(), nil
+}
+
+func isHex(value string) bool {
+	_, err := hex.DecodeString(value)
+
+	return err == nil
+}
+
+// newBlake2b returns an unkeyed BLAKE2b-512 hash, which is what b2sum computes by default
+func newBlake2b() hash.Hash {
+	// New512 only errors for keys longer than 64 bytes
+	blake2bHash, _ := blake2b.New512(nil)
+
+	return blake2bHash
+}
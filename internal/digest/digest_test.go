@@ -0,0 +1,111 @@
+package digest
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// checksums of "file a"
+const (
+	blake2bChecksum = "78f6794603ba54d396cba920c1c8d71fb1864d252b5a205cf7e45eb93fb77c3b16e71bf43d8e609b23fb628c6b42e9acdd053f1f6b8abdcba1f746ced0a8607e"
+	sha256Checksum  = "63c5cdfc617fb8fe93888e68674717590957a25b5e08219b1a61c3f031f88ee6"
+	sha384Checksum  = "913e9a16bc8f035892468b9f8e309893e5aaf9d5b5e512f54f95893cbfc5da56e13487bfd0429a62f6e620adcc873019"
+	sha512Checksum  = "a705aaf587ddc9ed135d4c318c339f3a0d6eb3a2e11936942afbfcd65254da6a1600b7b8e27f59464219fdc704f3b96c9953d80c05632411f475eea6f4548963"
+)
+
+var upperSha256Checksum = strings.ToUpper(sha256Checksum)
+
+func TestParse(t *testing.T) {
+	tests := map[string][2]string{
+		sha512Checksum:                  {"sha512", sha512Checksum},
+		"sha512:" + sha512Checksum:      {"sha512", sha512Checksum},
+		"sha256:" + upperSha256Checksum: {"sha256", sha256Checksum},
+		"sha384:" + sha384Checksum:      {"sha384", sha384Checksum},
+		"blake2b:" + blake2bChecksum:    {"blake2b", blake2bChecksum},
+	}
+
+	for checksum, expected := range tests {
+		algorithm, value, err := Parse(checksum)
+		if err != nil {
+			t.Fatalf("unexpected error when invoking Parse(%s): %v", checksum, err)
+		}
+
+		if algorithm != expected[0] || value != expected[1] {
+			t.Errorf("expected Parse(%s) to be %s and %s, but got %s and %s", checksum, expected[0], expected[1], algorithm, value)
+		}
+	}
+}
+
+func TestParseReturnsErrorForUnsupportedAlgorithm(t *testing.T) {
+	_, _, err := Parse("md5:3b5d3c7d207e37dceeedd301e35e2e58")
+	if err == nil {
+		t.Fatal("expected an error for an unsupported algorithm")
+	}
+
+	if err.Error() != "unsupported checksum algorithm md5 in md5:3b5d3c7d207e37dceeedd301e35e2e58, expected one of blake2b, sha256, sha384, sha512" {
+		t.Errorf("unexpected error message: %s", err.Error())
+	}
+}
+
+func TestParseReturnsErrorForInvalidValue(t *testing.T) {
+	for _, checksum := range []string{"sha256:../../x", "sha256:" + sha256Checksum[:63], "sha256:" + sha512Checksum, "hey"} {
+		if _, _, err := Parse(checksum); err == nil {
+			t.Errorf("expected an error for invalid checksum %s", checksum)
+		}
+	}
+
+	_, _, err := Parse("sha256:../../x")
+	if err == nil {
+		t.Fatal("expected an error for a checksum that isn't hex")
+	}
+
+	if err.Error() != "invalid checksum sha256:../../x, expected 64 hex characters for sha256" {
+		t.Errorf("unexpected error message: %s", err.Error())
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	tests := map[string]string{
+		"sha512:" + strings.ToUpper(sha512Checksum): sha512Checksum,
+		sha512Checksum:             sha512Checksum,
+		"sha256:" + sha256Checksum: "sha256:" + sha256Checksum,
+	}
+
+	for checksum, expected := range tests {
+		actual, err := Normalize(checksum)
+		if err != nil {
+			t.Fatalf("unexpected error when invoking Normalize(%s): %v", checksum, err)
+		}
+
+		if actual != expected {
+			t.Errorf("expected Normalize(%s) to be %s, but got %s", checksum, expected, actual)
+		}
+	}
+}
+
+func TestNew(t *testing.T) {
+	tests := map[string]string{
+		"blake2b": "78f6794603ba54d396cba920c1c8d71fb1864d252b5a205cf7e45eb93fb77c3b16e71bf43d8e609b23fb628c6b42e9acdd053f1f6b8abdcba1f746ced0a8607e",
+		"sha256":  "63c5cdfc617fb8fe93888e68674717590957a25b5e08219b1a61c3f031f88ee6",
+		"sha384":  "913e9a16bc8f035892468b9f8e309893e5aaf9d5b5e512f54f95893cbfc5da56e13487bfd0429a62f6e620adcc873019",
+		"sha512":  "a705aaf587ddc9ed135d4c318c339f3a0d6eb3a2e11936942afbfcd65254da6a1600b7b8e27f59464219fdc704f3b96c9953d80c05632411f475eea6f4548963",
+	}
+
+	for algorithm, expected := range tests {
+		fileHash, err := New(algorithm)
+		if err != nil {
+			t.Fatalf("unexpected error when invoking New(%s): %v", algorithm, err)
+		}
+
+		fileHash.Write([]byte("file a"))
+
+		if actual := fmt.Sprintf("%x", fileHash.Sum(nil)); actual != expected {
+			t.Errorf("expected %s of file a to be %s, but got %s", algorithm, expected, actual)
+		}
+	}
+
+	if _, err := New("md5"); err == nil {
+		t.Error("expected an error for an unsupported algorithm")
+	}
+}
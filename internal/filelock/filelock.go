@@ -0,0 +1,45 @@
+package filelock
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/apex/log"
+)
+
+// Lock blocks until this process holds an exclusive advisory lock on path, creating path if needed. While another
+// process holds the lock, a message is logged so it's clear why lockal is waiting. The returned function releases
+// the lock.
+func Lock(path string, logCtx *log.Entry) (func(), error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	locked, err := tryLock(file)
+	if err != nil {
+		file.Close()
+
+		return nil, fmt.Errorf("locking %s: %w", path, err)
+	}
+
+	if !locked {
+		logCtx.Info(fmt.Sprintf("waiting for another lockal process to release %s", path))
+
+		if err = lock(file); err != nil {
+			file.Close()
+
+			return nil, fmt.Errorf("locking %s: %w", path, err)
+		}
+	}
+
+	return func() {
+		unlock(file)
+		file.Close()
+	}, nil
+}
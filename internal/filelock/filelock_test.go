@@ -0,0 +1,64 @@
+package filelock
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/apex/log"
+	"github.com/apex/log/handlers/memory"
+)
+
+func getLogCtx() (*memory.Handler, *log.Entry) {
+	log.SetLevel(log.DebugLevel)
+	handler := memory.New()
+	log.SetHandler(handler)
+
+	return handler, log.WithFields(log.Fields{
+		"app": "lockal-test",
+	})
+}
+
+func TestLockWaitsForOtherHolder(t *testing.T) {
+	handler, logCtx := getLogCtx()
+
+	path := filepath.Join(t.TempDir(), "nested", "entry.lock")
+
+	unlock, err := Lock(path, logCtx)
+	if err != nil {
+		t.Fatalf("unexpected error when invoking Lock: %v", err)
+	}
+
+	acquired := make(chan struct{})
+
+	go func() {
+		secondUnlock, err := Lock(path, logCtx)
+		if err != nil {
+			t.Errorf("unexpected error when invoking Lock a second time: %v", err)
+
+			return
+		}
+
+		close(acquired)
+		secondUnlock()
+	}()
+
+	select {
+	case <-acquired:
+		t.Fatal("expected second Lock to wait until the first lock is released")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	unlock()
+
+	select {
+	case <-acquired:
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected second Lock to succeed after the first lock is released")
+	}
+
+	expectedMessage := "waiting for another lockal process to release " + path
+	if len(handler.Entries) != 1 || handler.Entries[0].Message != expectedMessage {
+		t.Errorf("expected a single log entry of %s, but got %+v", expectedMessage, handler.Entries)
+	}
+}
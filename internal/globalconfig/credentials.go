@@ -0,0 +1,89 @@
+package globalconfig
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+var nonAlphanumericPattern = regexp.MustCompile("[^A-Z0-9]+")
+
+// Credential authenticates downloads from Host with either a token or a username and password
+type Credential struct {
+	Host string `json:"host"`
+	// Token is sent as a bearer token in the Authorization header, unless Header names a different header to send it in
+	Token    string `json:"token,omitempty"`
+	Header   string `json:"header,omitempty"`
+	Username string `json:"username,omitempty"`
+	Password string `json:"password,omitempty"`
+}
+
+// GetCredentialEnvVars returns the names of the environment variables that provide a token, username, and password
+// for host, such as LOCKAL_TOKEN_GITHUB_COM
+func GetCredentialEnvVars(host string) (string, string, string) {
+	suffix := strings.Trim(nonAlphanumericPattern.ReplaceAllString(strings.ToUpper(host), "_"), "_")
+
+	return "LOCKAL_TOKEN_" + suffix, "LOCKAL_USERNAME_" + suffix, "LOCKAL_PASSWORD_" + suffix
+}
+
+// GetCredential returns the credential for location's host. Environment variables, read with getenv, take precedence
+// over credentials in the global config. false is returned when neither has a credential for the host, in which case
+// ~/.netrc is still used when downloading.
+func (globalConfig Config) GetCredential(location string, getenv func(string) string) (Credential, bool) {
+	parsedURL, err := url.Parse(location)
+	if err != nil || parsedURL.Host == "" {
+		return Credential{}, false
+	}
+
+	for _, host := range []string{parsedURL.Host, parsedURL.Hostname()} {
+		tokenEnvVar, usernameEnvVar, passwordEnvVar := GetCredentialEnvVars(host)
+
+		credential := Credential{
+			Host:     host,
+			Token:    getenv(tokenEnvVar),
+			Username: getenv(usernameEnvVar),
+			Password: getenv(passwordEnvVar),
+		}
+
+		if credential.Token != "" || credential.Username != "" {
+			return credential, true
+		}
+	}
+
+	for _, host := range []string{parsedURL.Host, parsedURL.Hostname()} {
+		for _, credential := range globalConfig.Credentials {
+			if strings.EqualFold(credential.Host, host) {
+				return credential, true
+			}
+		}
+	}
+
+	return Credential{}, false
+}
+
+// GetHeader returns the HTTP headers that authenticate with credential
+func (credential Credential) GetHeader() http.Header {
+	header := http.Header{}
+
+	if credential.Token != "" {
+		if credential.Header != "" {
+			header.Set(credential.Header, credential.Token)
+		} else {
+			header.Set("Authorization", fmt.Sprintf("Bearer %s", credential.Token))
+		}
+
+		return header
+	}
+
+	request := &http.Request{Header: header}
+	request.SetBasicAuth(credential.Username, credential.Password)
+
+	return header
+}
+
+// GetSecrets returns every secret in credential that must be kept out of logs
+func (credential Credential) GetSecrets() []string {
+	return []string{credential.Token, credential.Password}
+}
@@ -0,0 +1,58 @@
+package globalconfig
+
+import (
+	"testing"
+)
+
+func TestGetCredential(t *testing.T) {
+	globalConfig := Config{
+		Credentials: []Credential{
+			{Host: "gitlab.corp", Token: "config-token", Header: "PRIVATE-TOKEN"},
+			{Host: "artifacts.corp:8443", Username: "robot", Password: "config-password"},
+			{Host: "github.com", Token: "config-github-token"},
+		},
+	}
+
+	env := map[string]string{
+		"LOCKAL_TOKEN_GITHUB_COM": "env-github-token",
+	}
+
+	getenv := func(name string) string {
+		return env[name]
+	}
+
+	credential, ok := globalConfig.GetCredential("https://github.com/org/tool/releases/download/v1/tool", getenv)
+	if !ok || credential.GetHeader().Get("Authorization") != "Bearer env-github-token" {
+		t.Errorf("expected environment variable to take precedence, but got %+v", credential)
+	}
+
+	credential, ok = globalConfig.GetCredential("https://gitlab.corp/api/v4/projects/1/packages/tool", getenv)
+	if !ok || credential.GetHeader().Get("PRIVATE-TOKEN") != "config-token" {
+		t.Errorf("expected token to be sent in PRIVATE-TOKEN header, but got %+v", credential)
+	}
+
+	credential, ok = globalConfig.GetCredential("https://artifacts.corp:8443/tool", getenv)
+	if !ok || credential.GetHeader().Get("Authorization") != "Basic cm9ib3Q6Y29uZmlnLXBhc3N3b3Jk" {
+		t.Errorf("expected basic auth for host with port, but got %+v", credential)
+	}
+
+	if credential, ok = globalConfig.GetCredential("https://example.com/tool", getenv); ok {
+		t.Errorf("expected no credential for example.com, but got %+v", credential)
+	}
+}
+
+func TestGetCredentialEnvVars(t *testing.T) {
+	tokenEnvVar, usernameEnvVar, passwordEnvVar := GetCredentialEnvVars("artifacts.corp:8443")
+
+	if tokenEnvVar != "LOCKAL_TOKEN_ARTIFACTS_CORP_8443" {
+		t.Errorf("unexpected token environment variable: %s", tokenEnvVar)
+	}
+
+	if usernameEnvVar != "LOCKAL_USERNAME_ARTIFACTS_CORP_8443" {
+		t.Errorf("unexpected username environment variable: %s", usernameEnvVar)
+	}
+
+	if passwordEnvVar != "LOCKAL_PASSWORD_ARTIFACTS_CORP_8443" {
+		t.Errorf("unexpected password environment variable: %s", passwordEnvVar)
+	}
+}
@@ -0,0 +1,113 @@
+package globalconfig
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/apex/log"
+	"github.com/spf13/afero"
+)
+
+// Rewrite replaces Prefix with ReplaceWith in every location starting with Prefix
+type Rewrite struct {
+	Prefix      string `json:"prefix"`
+	ReplaceWith string `json:"replace_with"`
+}
+
+// Mirror lists locations to try, in order, when downloading a location starting with Prefix fails
+type Mirror struct {
+	Prefix  string   `json:"prefix"`
+	Mirrors []string `json:"mirrors"`
+}
+
+// Config is the user's lockal configuration shared by every project
+type Config struct {
+	Rewrites    []Rewrite    `json:"rewrites"`
+	Mirrors     []Mirror     `json:"mirrors"`
+	Credentials []Credential `json:"credentials"`
+}
+
+// GetDefaultPath returns $XDG_CONFIG_HOME/lockal/config, falling back to ~/.config when XDG_CONFIG_HOME isn't set
+func GetDefaultPath(userHomeDir string) string {
+	configHome := os.Getenv("XDG_CONFIG_HOME")
+	if configHome == "" {
+		configHome = fmt.Sprintf("%s/.config", userHomeDir)
+	}
+
+	return fmt.Sprintf("%s/lockal/config", configHome)
+}
+
+// Read returns the config at path, or an empty config if path doesn't exist
+func Read(fs afero.Fs, path string) (Config, error) {
+	globalConfig := Config{}
+
+	content, err := afero.ReadFile(fs, path)
+	if os.IsNotExist(err) {
+		return globalConfig, nil
+	}
+	if err != nil {
+		return globalConfig, err
+	}
+
+	if err = json.Unmarshal(content, &globalConfig); err != nil {
+		return globalConfig, fmt.Errorf("parsing %s: %w", path, err)
+	}
+
+	return globalConfig, nil
+}
+
+// GetLocations returns every location to try downloading location from, in order. The first rewrite with a matching
+// prefix is applied to location, followed by each matching mirror.
+func (globalConfig Config) GetLocations(location string) []string {
+	locations := []string{location}
+
+	for _, rewrite := range globalConfig.Rewrites {
+		if strings.HasPrefix(location, rewrite.Prefix) {
+			locations[0] = rewrite.ReplaceWith + strings.TrimPrefix(location, rewrite.Prefix)
+
+			break
+		}
+	}
+
+	for _, mirror := range globalConfig.Mirrors {
+		if !strings.HasPrefix(location, mirror.Prefix) {
+			continue
+		}
+
+		for _, mirrorPrefix := range mirror.Mirrors {
+			locations = append(locations, mirrorPrefix+strings.TrimPrefix(location, mirror.Prefix))
+		}
+	}
+
+	return locations
+}
+
+// WrapGetFile returns a getFile that tries each of the locations from GetLocations until one succeeds
+func (globalConfig Config) WrapGetFile(logCtx *log.Entry, getFile func(dest, src string, hash io.Writer) error) func(dest, src string, hash io.Writer) error {
+	return func(dest, src string, hash io.Writer) error {
+		locations := globalConfig.GetLocations(src)
+
+		var err error
+
+		for i, location := range locations {
+			if location != src {
+				logCtx.Debug(fmt.Sprintf("downloading %s from %s", src, location))
+			}
+
+			// a failed attempt may have written part of the file to hash, the caller re-hashes dest when what was
+			// written to hash doesn't match what was downloaded
+			if err = getFile(dest, location, hash); err == nil {
+				return nil
+			}
+
+			if i < len(locations)-1 {
+				logCtx.WithError(err).Warn(fmt.Sprintf("unable to download %s, trying %s", location, locations[i+1]))
+			}
+		}
+
+		return err
+	}
+}
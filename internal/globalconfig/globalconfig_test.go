@@ -0,0 +1,135 @@
+package globalconfig
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"testing"
+
+	"github.com/apex/log"
+	"github.com/apex/log/handlers/memory"
+	"github.com/spf13/afero"
+)
+
+func getLogCtx() *log.Entry {
+	log.SetLevel(log.DebugLevel)
+	log.SetHandler(memory.New())
+
+	return log.WithFields(log.Fields{
+		"app": "lockal-test",
+	})
+}
+
+func TestReadReturnsEmptyConfigWhenNotFound(t *testing.T) {
+	globalConfig, err := Read(afero.NewMemMapFs(), "/home/.config/lockal/config")
+	if err != nil {
+		t.Fatalf("unexpected error when invoking Read: %v", err)
+	}
+
+	locations := globalConfig.GetLocations("https://github.com/a")
+	if !reflect.DeepEqual(locations, []string{"https://github.com/a"}) {
+		t.Errorf("expected location to be unchanged, but got %v", locations)
+	}
+}
+
+func TestGetLocations(t *testing.T) {
+	fs := afero.NewMemMapFs()
+
+	content := `{
+  "rewrites": [
+    {"prefix": "https://github.com/", "replace_with": "https://artifacts.corp/github/"}
+  ],
+  "mirrors": [
+    {"prefix": "https://github.com/", "mirrors": ["https://mirror-a.corp/github/", "https://mirror-b.corp/github/"]},
+    {"prefix": "https://get.helm.sh/", "mirrors": ["https://mirror-a.corp/helm/"]}
+  ]
+}`
+
+	if err := afero.WriteFile(fs, "/home/.config/lockal/config", []byte(content), 0644); err != nil {
+		t.Fatalf("unexpected error creating config: %v", err)
+	}
+
+	globalConfig, err := Read(fs, "/home/.config/lockal/config")
+	if err != nil {
+		t.Fatalf("unexpected error when invoking Read: %v", err)
+	}
+
+	tests := map[string][]string{
+		"https://github.com/kind/releases/kind": {
+			"https://artifacts.corp/github/kind/releases/kind",
+			"https://mirror-a.corp/github/kind/releases/kind",
+			"https://mirror-b.corp/github/kind/releases/kind",
+		},
+		"https://get.helm.sh/helm.tar.gz?archive=false": {
+			"https://get.helm.sh/helm.tar.gz?archive=false",
+			"https://mirror-a.corp/helm/helm.tar.gz?archive=false",
+		},
+		"https://example.com/tool": {
+			"https://example.com/tool",
+		},
+	}
+
+	for location, expectedLocations := range tests {
+		locations := globalConfig.GetLocations(location)
+		if !reflect.DeepEqual(locations, expectedLocations) {
+			t.Errorf("expected locations for %s to be %v, but got %v", location, expectedLocations, locations)
+		}
+	}
+}
+
+func TestReadReturnsErrorForInvalidConfig(t *testing.T) {
+	fs := afero.NewMemMapFs()
+
+	if err := afero.WriteFile(fs, "/config", []byte("rewrites"), 0644); err != nil {
+		t.Fatalf("unexpected error creating config: %v", err)
+	}
+
+	_, err := Read(fs, "/config")
+	if err == nil {
+		t.Fatal("expected an error for invalid config")
+	}
+
+	if err.Error() != "parsing /config: invalid character 'r' looking for beginning of value" {
+		t.Errorf("unexpected error message: %s", err.Error())
+	}
+}
+
+func TestWrapGetFileFallsBackToMirrors(t *testing.T) {
+	globalConfig := Config{
+		Mirrors: []Mirror{
+			{Prefix: "https://github.com/", Mirrors: []string{"https://mirror-a.corp/", "https://mirror-b.corp/"}},
+		},
+	}
+
+	attempted := []string{}
+
+	getFile := globalConfig.WrapGetFile(getLogCtx(), func(dest, src string, hash io.Writer) error {
+		attempted = append(attempted, src)
+
+		if src != "https://mirror-b.corp/kind" {
+			return fmt.Errorf("unable to reach %s", src)
+		}
+
+		return nil
+	})
+
+	if err := getFile("/dest", "https://github.com/kind", nil); err != nil {
+		t.Fatalf("unexpected error when invoking getFile: %v", err)
+	}
+
+	expectedAttempts := []string{"https://github.com/kind", "https://mirror-a.corp/kind", "https://mirror-b.corp/kind"}
+	if !reflect.DeepEqual(attempted, expectedAttempts) {
+		t.Errorf("expected attempts of %v, but got %v", expectedAttempts, attempted)
+	}
+
+	attempted = []string{}
+
+	err := getFile("/dest", "https://example.com/tool", nil)
+	if err == nil {
+		t.Fatal("expected an error when every location fails")
+	}
+
+	if err.Error() != "unable to reach https://example.com/tool" {
+		t.Errorf("unexpected error message: %s", err.Error())
+	}
+}
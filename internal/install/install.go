@@ -0,0 +1,197 @@
+package install
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/apex/log"
+
+	"github.com/dustinspecker/lockal/internal/config"
+	"github.com/dustinspecker/lockal/internal/dependency"
+)
+
+// Errors is returned when more than one dependency failed to install
+type Errors []error
+
+func (errs Errors) Error() string {
+	messages := []string{}
+	for _, err := range errs {
+		messages = append(messages, err.Error())
+	}
+
+	return fmt.Sprintf("failed to install %d dependencies: %s", len(errs), strings.Join(messages, "; "))
+}
+
+// Run downloads deps using up to jobs workers at the same time.
+//
+// Dependencies sharing a name are downloaded one after another in the order they were declared.
+// Once a dependency fails no new downloads are started, but downloads already in progress are
+// allowed to finish. Every failure is returned along with the dependencies that were installed.
+func Run(cfg config.Config, deps []dependency.Dependency, jobs int) ([]dependency.Dependency, error) {
+	if jobs < 1 {
+		return nil, fmt.Errorf("jobs must be at least 1, but got %d", jobs)
+	}
+
+	groups := groupByName(deps)
+	groupInstalled := make([][]dependency.Dependency, len(groups))
+	groupErrs := make([]error, len(groups))
+
+	work := make(chan int)
+	failed := make(chan struct{})
+	var failOnce sync.Once
+
+	var wg sync.WaitGroup
+	for i := 0; i < jobs; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for groupIndex := range work {
+				installed, err := downloadGroup(cfg, groups[groupIndex])
+				groupInstalled[groupIndex] = installed
+
+				if err != nil {
+					groupErrs[groupIndex] = err
+					failOnce.Do(func() { close(failed) })
+				}
+			}
+		}()
+	}
+
+schedule:
+	for groupIndex := range groups {
+		select {
+		case <-failed:
+			break schedule
+		default:
+		}
+
+		select {
+		case work <- groupIndex:
+		case <-failed:
+			break schedule
+		}
+	}
+
+	close(work)
+	wg.Wait()
+
+	installed := []dependency.Dependency{}
+	for _, groupDeps := range groupInstalled {
+		installed = append(installed, groupDeps...)
+	}
+
+	errs := Errors{}
+	for _, err := range groupErrs {
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if len(errs) == 0 {
+		return installed, nil
+	}
+
+	if len(errs) == 1 {
+		return installed, errs[0]
+	}
+
+	return installed, errs
+}
+
+// CheckOffline returns an error naming every dependency that can't be installed without downloading something
+func CheckOffline(cfg config.Config, deps []dependency.Dependency) error {
+	unavailable := []string{}
+
+	for _, dep := range deps {
+		plan, err := dep.Plan(cfg)
+		if err != nil {
+			return fmt.Errorf("%s: %w", dep.GetName(), err)
+		}
+
+		if plan.Action == dependency.ActionDownload {
+			unavailable = append(unavailable, dep.GetName())
+		}
+	}
+
+	if len(unavailable) > 0 {
+		return fmt.Errorf("unable to install offline since the following dependencies are not in the cache: %s", strings.Join(unavailable, ", "))
+	}
+
+	return nil
+}
+
+// DryRun logs what Run would do for each dependency without doing any of it
+func DryRun(cfg config.Config, deps []dependency.Dependency) error {
+	for _, dep := range deps {
+		plan, err := dep.Plan(cfg)
+		if err != nil {
+			return fmt.Errorf("%s: %w", dep.GetName(), err)
+		}
+
+		logCtx := cfg.LogCtx.WithFields(log.Fields{
+			"dependency": dep.GetName(),
+			"action":     plan.Action,
+		})
+
+		if plan.Action == dependency.ActionSkip {
+			logCtx.Info(fmt.Sprintf("would skip %s as it already exists", dep.GetName()))
+
+			continue
+		}
+
+		if plan.Status == dependency.StatusChecksumMismatch {
+			logCtx.Info(fmt.Sprintf("would replace %s since it doesn't match expected checksum", dep.GetName()))
+		}
+
+		switch plan.Action {
+		case dependency.ActionCopyFromCache:
+			logCtx.Info(fmt.Sprintf("would copy %s from %s", dep.GetName(), plan.Source))
+		case dependency.ActionExtractFromCachedArchive:
+			logCtx.Info(fmt.Sprintf("would extract %s from cached archive %s", dep.GetName(), plan.Source))
+		case dependency.ActionDownload:
+			logCtx.Info(fmt.Sprintf("would download %s from %s", dep.GetName(), plan.Source))
+		}
+	}
+
+	return nil
+}
+
+func downloadGroup(cfg config.Config, group []dependency.Dependency) ([]dependency.Dependency, error) {
+	installed := []dependency.Dependency{}
+
+	for _, dep := range group {
+		depCfg := cfg
+		depCfg.LogCtx = cfg.LogCtx.WithField("dependency", dep.GetName())
+
+		if err := dep.Download(depCfg); err != nil {
+			depCfg.LogCtx.WithError(err).Error("failed to install")
+
+			return installed, fmt.Errorf("%s: %w", dep.GetName(), err)
+		}
+
+		installed = append(installed, dep)
+	}
+
+	return installed, nil
+}
+
+func groupByName(deps []dependency.Dependency) [][]dependency.Dependency {
+	groups := [][]dependency.Dependency{}
+	groupIndexByName := map[string]int{}
+
+	for _, dep := range deps {
+		groupIndex, ok := groupIndexByName[dep.GetName()]
+		if !ok {
+			groupIndex = len(groups)
+			groupIndexByName[dep.GetName()] = groupIndex
+			groups = append(groups, []dependency.Dependency{})
+		}
+
+		groups[groupIndex] = append(groups[groupIndex], dep)
+	}
+
+	return groups
+}
@@ -0,0 +1,305 @@
+package install
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/apex/log"
+	"github.com/apex/log/handlers/memory"
+	"github.com/spf13/afero"
+
+	"github.com/dustinspecker/lockal/internal/config"
+	"github.com/dustinspecker/lockal/internal/dependency"
+)
+
+type fakeDependency struct {
+	name     string
+	download func(config.Config) error
+}
+
+func (dep fakeDependency) GetName() string {
+	return dep.name
+}
+
+func (dep fakeDependency) Cache(cfg config.Config) ([]string, error) {
+	return nil, nil
+}
+
+func (dep fakeDependency) Download(cfg config.Config) error {
+	return dep.download(cfg)
+}
+
+func (dep fakeDependency) Verify(cfg config.Config) (dependency.Status, error) {
+	return dependency.StatusOK, nil
+}
+
+func (dep fakeDependency) Plan(cfg config.Config) (dependency.Plan, error) {
+	return dependency.Plan{Status: dependency.StatusMissing, Action: dependency.ActionDownload}, nil
+}
+
+func getConfig() (*memory.Handler, config.Config) {
+	log.SetLevel(log.DebugLevel)
+	logHandler := memory.New()
+	log.SetHandler(logHandler)
+
+	cfg := config.Config{
+		LogCtx: log.WithFields(log.Fields{
+			"app": "lockal-test",
+		}),
+	}
+
+	return logHandler, cfg
+}
+
+func TestRunDownloadsDependenciesAtTheSameTime(t *testing.T) {
+	_, cfg := getConfig()
+
+	var started sync.WaitGroup
+	started.Add(2)
+
+	waitForOtherDownload := func(cfg config.Config) error {
+		started.Done()
+
+		done := make(chan struct{})
+		go func() {
+			started.Wait()
+			close(done)
+		}()
+
+		select {
+		case <-done:
+			return nil
+		case <-time.After(5 * time.Second):
+			return fmt.Errorf("other download was never started")
+		}
+	}
+
+	deps := []dependency.Dependency{
+		fakeDependency{name: "bin/a", download: waitForOtherDownload},
+		fakeDependency{name: "bin/b", download: waitForOtherDownload},
+	}
+
+	if _, err := Run(cfg, deps, 2); err != nil {
+		t.Fatalf("unexpected error when invoking Run: %v", err)
+	}
+}
+
+func TestRunAddsDependencyNameToLogs(t *testing.T) {
+	logHandler, cfg := getConfig()
+
+	deps := []dependency.Dependency{
+		fakeDependency{name: "bin/a", download: func(cfg config.Config) error {
+			cfg.LogCtx.Info("downloading")
+
+			return nil
+		}},
+	}
+
+	if _, err := Run(cfg, deps, 1); err != nil {
+		t.Fatalf("unexpected error when invoking Run: %v", err)
+	}
+
+	if len(logHandler.Entries) != 1 {
+		t.Fatalf("expected 1 log entry, but got %d", len(logHandler.Entries))
+	}
+
+	if logHandler.Entries[0].Fields["dependency"] != "bin/a" {
+		t.Errorf("expected log entry to have dependency field of bin/a, but got %v", logHandler.Entries[0].Fields)
+	}
+}
+
+func TestRunStopsStartingDownloadsAfterFailure(t *testing.T) {
+	_, cfg := getConfig()
+
+	secondCalled := false
+
+	deps := []dependency.Dependency{
+		fakeDependency{name: "bin/a", download: func(cfg config.Config) error {
+			return fmt.Errorf("some error")
+		}},
+		fakeDependency{name: "bin/b", download: func(cfg config.Config) error {
+			secondCalled = true
+
+			return nil
+		}},
+	}
+
+	installed, err := Run(cfg, deps, 1)
+	if err == nil {
+		t.Fatal("expected an error when a download fails")
+	}
+
+	if err.Error() != "bin/a: some error" {
+		t.Errorf("expected error message of \"bin/a: some error\", but got \"%s\"", err.Error())
+	}
+
+	if secondCalled {
+		t.Error("expected bin/b to not be downloaded after bin/a failed")
+	}
+
+	if len(installed) != 0 {
+		t.Errorf("expected no dependencies to be installed, but got %d", len(installed))
+	}
+}
+
+func TestRunReturnsEveryFailure(t *testing.T) {
+	_, cfg := getConfig()
+
+	var started sync.WaitGroup
+	started.Add(2)
+
+	failAfterOtherStarted := func(cfg config.Config) error {
+		started.Done()
+		started.Wait()
+
+		return fmt.Errorf("some error")
+	}
+
+	deps := []dependency.Dependency{
+		fakeDependency{name: "bin/a", download: failAfterOtherStarted},
+		fakeDependency{name: "bin/b", download: failAfterOtherStarted},
+	}
+
+	_, err := Run(cfg, deps, 2)
+	if err == nil {
+		t.Fatal("expected an error when downloads fail")
+	}
+
+	expectedErrorMessage := "failed to install 2 dependencies: bin/a: some error; bin/b: some error"
+	if err.Error() != expectedErrorMessage {
+		t.Errorf("expected error message of \"%s\", but got \"%s\"", expectedErrorMessage, err.Error())
+	}
+}
+
+func TestRunDownloadsDependenciesWithSameNameInOrder(t *testing.T) {
+	_, cfg := getConfig()
+
+	var mu sync.Mutex
+	order := []string{}
+
+	record := func(id string) func(config.Config) error {
+		return func(cfg config.Config) error {
+			mu.Lock()
+			defer mu.Unlock()
+
+			order = append(order, id)
+
+			return nil
+		}
+	}
+
+	deps := []dependency.Dependency{
+		fakeDependency{name: "bin/a", download: record("first")},
+		fakeDependency{name: "bin/a", download: record("second")},
+	}
+
+	if _, err := Run(cfg, deps, 2); err != nil {
+		t.Fatalf("unexpected error when invoking Run: %v", err)
+	}
+
+	if len(order) != 2 || order[0] != "first" || order[1] != "second" {
+		t.Errorf("expected dependencies with the same name to be downloaded in order, but got %v", order)
+	}
+}
+
+func TestRunReturnsErrorWhenJobsIsInvalid(t *testing.T) {
+	_, cfg := getConfig()
+
+	if _, err := Run(cfg, nil, 0); err == nil {
+		t.Fatal("expected an error when jobs is 0")
+	}
+}
+
+func TestCheckOffline(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	_, cfg := getConfig()
+	cfg.CacheDir = "/.cache"
+	cfg.Fs = fs
+
+	checksum := "a705aaf587ddc9ed135d4c318c339f3a0d6eb3a2e11936942afbfcd65254da6a1600b7b8e27f59464219fdc704f3b96c9953d80c05632411f475eea6f4548963"
+
+	if err := afero.WriteFile(fs, "bin/installed", []byte("file a"), 0755); err != nil {
+		t.Fatalf("unexpected error creating bin/installed: %v", err)
+	}
+
+	if err := afero.WriteFile(fs, "/.cache/lockal/sha512/a7/"+checksum, []byte("file a"), 0644); err != nil {
+		t.Fatalf("unexpected error creating cache entry: %v", err)
+	}
+
+	deps := []dependency.Dependency{
+		dependency.Executable{Name: "bin/installed", Location: "some.sh/installed", Checksum: checksum},
+		dependency.Executable{Name: "bin/cached", Location: "some.sh/cached", Checksum: checksum},
+		dependency.Executable{Name: "bin/missing", Location: "some.sh/missing", Checksum: "b" + checksum[1:]},
+		dependency.ExecutableFromArchive{Name: "bin/archived", Location: "some.sh/archive.tgz", ArchiveChecksum: checksum, ExecutableChecksum: "c" + checksum[1:]},
+		dependency.ExecutableFromArchive{Name: "bin/missing-archive", Location: "some.sh/missing.tgz", ArchiveChecksum: "d" + checksum[1:], ExecutableChecksum: "e" + checksum[1:]},
+	}
+
+	err := CheckOffline(cfg, deps)
+	if err == nil {
+		t.Fatal("expected an error when dependencies are not in the cache")
+	}
+
+	expectedErrorMessage := "unable to install offline since the following dependencies are not in the cache: bin/missing, bin/missing-archive"
+	if err.Error() != expectedErrorMessage {
+		t.Errorf("expected error message of \"%s\", but got \"%s\"", expectedErrorMessage, err.Error())
+	}
+
+	availableDeps := []dependency.Dependency{deps[0], deps[1], deps[3]}
+
+	if err = CheckOffline(cfg, availableDeps); err != nil {
+		t.Errorf("expected no error when every dependency is available offline, but got %v", err)
+	}
+}
+
+func TestDryRun(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	logHandler, cfg := getConfig()
+	cfg.CacheDir = "/.cache"
+	cfg.Fs = afero.NewReadOnlyFs(fs)
+
+	checksum := "a705aaf587ddc9ed135d4c318c339f3a0d6eb3a2e11936942afbfcd65254da6a1600b7b8e27f59464219fdc704f3b96c9953d80c05632411f475eea6f4548963"
+	cache := "/.cache/lockal/sha512/a7/" + checksum
+
+	if err := afero.WriteFile(fs, "bin/installed", []byte("file a"), 0755); err != nil {
+		t.Fatalf("unexpected error creating bin/installed: %v", err)
+	}
+
+	if err := afero.WriteFile(fs, "bin/stale", []byte("old file a"), 0755); err != nil {
+		t.Fatalf("unexpected error creating bin/stale: %v", err)
+	}
+
+	if err := afero.WriteFile(fs, cache, []byte("file a"), 0644); err != nil {
+		t.Fatalf("unexpected error creating cache entry: %v", err)
+	}
+
+	deps := []dependency.Dependency{
+		dependency.Executable{Name: "bin/installed", Location: "some.sh/installed", Checksum: checksum},
+		dependency.Executable{Name: "bin/stale", Location: "some.sh/stale", Checksum: checksum},
+		dependency.Executable{Name: "bin/missing", Location: "some.sh/missing", Checksum: "b" + checksum[1:]},
+		dependency.ExecutableFromArchive{Name: "bin/archived", Location: "some.sh/archive.tgz", ArchiveChecksum: checksum, ExecutableChecksum: "c" + checksum[1:]},
+	}
+
+	if err := DryRun(cfg, deps); err != nil {
+		t.Fatalf("unexpected error when invoking DryRun: %v", err)
+	}
+
+	expectedMessages := []string{
+		"would skip bin/installed as it already exists",
+		"would replace bin/stale since it doesn't match expected checksum",
+		"would copy bin/stale from " + cache,
+		"would download bin/missing from some.sh/missing",
+		"would extract bin/archived from cached archive " + cache,
+	}
+
+	if len(logHandler.Entries) != len(expectedMessages) {
+		t.Fatalf("expected %d log entries, but got %d", len(expectedMessages), len(logHandler.Entries))
+	}
+
+	for i, expectedMessage := range expectedMessages {
+		if logHandler.Entries[i].Message != expectedMessage {
+			t.Errorf("expected log message of \"%s\", but got \"%s\"", expectedMessage, logHandler.Entries[i].Message)
+		}
+	}
+}
@@ -0,0 +1,225 @@
+package lock
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/spf13/afero"
+
+	"github.com/dustinspecker/lockal/internal/config"
+	"github.com/dustinspecker/lockal/internal/dependency"
+	"github.com/dustinspecker/lockal/internal/parse"
+)
+
+const (
+	Filename = "lockal.lock"
+	version  = 1
+)
+
+// DefaultPlatforms are the os/arch combinations locked when none are provided
+var DefaultPlatforms = []string{
+	"darwin/amd64",
+	"darwin/arm64",
+	"linux/amd64",
+	"linux/arm64",
+}
+
+// Entry is the resolved state of a single dependency
+type Entry struct {
+	Name               string `json:"name"`
+	Rule               string `json:"rule"`
+	Location           string `json:"location"`
+	Checksum           string `json:"checksum,omitempty"`
+	ArchiveChecksum    string `json:"archive_checksum,omitempty"`
+	ExtractFilepath    string `json:"extract_filepath,omitempty"`
+	ExecutableChecksum string `json:"executable_checksum,omitempty"`
+	// ChecksumURL is the checksum file Checksum or ArchiveChecksum was read from, pinned by ChecksumURLChecksum
+	ChecksumURL         string `json:"checksum_url,omitempty"`
+	ChecksumURLChecksum string `json:"checksum_url_checksum,omitempty"`
+	// SignatureURL is the signature of the downloaded file, made with SignatureKey or the key named SignatureKeyName
+	SignatureURL     string `json:"signature_url,omitempty"`
+	SignatureKey     string `json:"signature_key,omitempty"`
+	SignatureKeyName string `json:"signature_key_name,omitempty"`
+}
+
+// InstalledChecksum is the checksum of the file installed for entry
+func (entry Entry) InstalledChecksum() string {
+	if entry.Rule == "executable_from_archive" {
+		return entry.ExecutableChecksum
+	}
+
+	return entry.Checksum
+}
+
+// File is the content of lockal.lock
+type File struct {
+	Version   int                `json:"version"`
+	Platforms map[string][]Entry `json:"platforms"`
+}
+
+// Create evaluates lockal.star for each platform and records the resolved dependencies, reading checksums from
+// checksum files when needed
+func Create(cfg config.Config, platforms []string) (File, error) {
+	lockFile := File{
+		Version:   version,
+		Platforms: map[string][]Entry{},
+	}
+
+	for _, platform := range platforms {
+		operatingSystem, architecture, err := ParsePlatform(platform)
+		if err != nil {
+			return lockFile, err
+		}
+
+		deps, err := parse.GetDependenciesForPlatform(cfg.Fs, operatingSystem, architecture)
+		if err != nil {
+			return lockFile, fmt.Errorf("evaluating lockal.star for %s: %w", platform, err)
+		}
+
+		if deps, err = dependency.ResolveChecksums(cfg, deps); err != nil {
+			return lockFile, fmt.Errorf("resolving checksums for %s: %w", platform, err)
+		}
+
+		entries, err := GetEntries(deps)
+		if err != nil {
+			return lockFile, err
+		}
+
+		lockFile.Platforms[platform] = entries
+	}
+
+	return lockFile, nil
+}
+
+// ParsePlatform splits a platform such as linux/amd64 into its operating system and architecture
+func ParsePlatform(platform string) (string, string, error) {
+	parts := strings.Split(platform, "/")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", fmt.Errorf("invalid platform %s, expected format of os/arch such as linux/amd64", platform)
+	}
+
+	return parts[0], parts[1], nil
+}
+
+func Read(fs afero.Fs) (File, error) {
+	lockFile := File{}
+
+	content, err := afero.ReadFile(fs, Filename)
+	if err != nil {
+		return lockFile, err
+	}
+
+	if err = json.Unmarshal(content, &lockFile); err != nil {
+		return lockFile, fmt.Errorf("parsing %s: %w", Filename, err)
+	}
+
+	if lockFile.Version != version {
+		return lockFile, fmt.Errorf("unsupported %s version %d, expected %d", Filename, lockFile.Version, version)
+	}
+
+	return lockFile, nil
+}
+
+func Write(fs afero.Fs, lockFile File) error {
+	content, err := json.MarshalIndent(lockFile, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return afero.WriteFile(fs, Filename, append(content, '\n'), 0644)
+}
+
+// Check returns an error describing every difference between deps and the dependencies locked for platform
+func Check(lockFile File, platform string, deps []dependency.Dependency) error {
+	lockedEntries, ok := lockFile.Platforms[platform]
+	if !ok {
+		return fmt.Errorf("%s does not contain platform %s, run lockal lock", Filename, platform)
+	}
+
+	entries, err := GetEntries(deps)
+	if err != nil {
+		return err
+	}
+
+	lockedEntriesByName := map[string]Entry{}
+	for _, entry := range lockedEntries {
+		lockedEntriesByName[entry.Name] = entry
+	}
+
+	entriesByName := map[string]Entry{}
+	for _, entry := range entries {
+		entriesByName[entry.Name] = entry
+	}
+
+	differences := []string{}
+
+	for name, entry := range entriesByName {
+		lockedEntry, ok := lockedEntriesByName[name]
+		if !ok {
+			differences = append(differences, fmt.Sprintf("%s is not locked", name))
+
+			continue
+		}
+
+		if !reflect.DeepEqual(entry, lockedEntry) {
+			differences = append(differences, fmt.Sprintf("%s does not match locked version", name))
+		}
+	}
+
+	for name := range lockedEntriesByName {
+		if _, ok := entriesByName[name]; !ok {
+			differences = append(differences, fmt.Sprintf("%s is locked but no longer in lockal.star", name))
+		}
+	}
+
+	if len(differences) > 0 {
+		sort.Strings(differences)
+
+		return fmt.Errorf("lockal.star does not match %s for %s, run lockal lock: %s", Filename, platform, strings.Join(differences, "; "))
+	}
+
+	return nil
+}
+
+// GetEntries resolves deps into entries that can be recorded
+func GetEntries(deps []dependency.Dependency) ([]Entry, error) {
+	entries := []Entry{}
+
+	for _, dep := range deps {
+		switch dep := dep.(type) {
+		case dependency.Executable:
+			entries = append(entries, Entry{
+				Name:                dep.Name,
+				Rule:                "executable",
+				Location:            dep.Location,
+				Checksum:            dep.Checksum,
+				ChecksumURL:         dep.ChecksumFile.Location,
+				ChecksumURLChecksum: dep.ChecksumFile.Checksum,
+				SignatureURL:        dep.Signature.Location,
+				SignatureKey:        dep.Signature.Key,
+				SignatureKeyName:    dep.Signature.KeyName,
+			})
+		case dependency.ExecutableFromArchive:
+			entries = append(entries, Entry{
+				Name:                dep.Name,
+				Rule:                "executable_from_archive",
+				Location:            dep.Location,
+				ArchiveChecksum:     dep.ArchiveChecksum,
+				ExtractFilepath:     dep.ExtractFilepath,
+				ExecutableChecksum:  dep.ExecutableChecksum,
+				ChecksumURL:         dep.ChecksumFile.Location,
+				ChecksumURLChecksum: dep.ChecksumFile.Checksum,
+				SignatureURL:        dep.Signature.Location,
+				SignatureKey:        dep.Signature.Key,
+				SignatureKeyName:    dep.Signature.KeyName,
+			})
+		default:
+			return entries, fmt.Errorf("unable to lock %s, unknown dependency type %T", dep.GetName(), dep)
+		}
+	}
+
+	return entries, nil
+}
@@ -0,0 +1,137 @@
+package lock
+
+import (
+	"testing"
+
+	"github.com/apex/log"
+	"github.com/spf13/afero"
+
+	"github.com/dustinspecker/lockal/internal/config"
+	"github.com/dustinspecker/lockal/internal/dependency"
+)
+
+const lockalStar = `
+def get_checksum(os, arch):
+	if os == "linux":
+		return "linux_sum"
+
+	return "darwin_sum"
+
+executable(
+	name = "bin/cloud",
+	location = "sky/cloud-%(os)s-%(arch)s" % dict(os = LOCKAL_OS, arch = LOCKAL_ARCH),
+	checksum = get_checksum(LOCKAL_OS, LOCKAL_ARCH),
+)
+
+executable_from_archive(
+	name = "bin/record",
+	location = "library/archives.tgz",
+	archive_checksum = "archive_sum",
+	extract_filepath = "bin/record",
+	executable_checksum = "exe_sum",
+)
+`
+
+func getConfig(fs afero.Fs) config.Config {
+	return config.Config{
+		CacheDir: "/cache",
+		Fs:       fs,
+		LogCtx:   log.WithField("app", "lockal-test"),
+	}
+}
+
+func TestCreateWriteAndRead(t *testing.T) {
+	fs := afero.NewMemMapFs()
+
+	if err := afero.WriteFile(fs, "lockal.star", []byte(lockalStar), 0644); err != nil {
+		t.Fatalf("unexpected error while creating lockal.star: %v", err)
+	}
+
+	lockFile, err := Create(getConfig(fs), []string{"linux/amd64", "darwin/arm64"})
+	if err != nil {
+		t.Fatalf("unexpected error when invoking Create: %v", err)
+	}
+
+	if err = Write(fs, lockFile); err != nil {
+		t.Fatalf("unexpected error when invoking Write: %v", err)
+	}
+
+	readLockFile, err := Read(fs)
+	if err != nil {
+		t.Fatalf("unexpected error when invoking Read: %v", err)
+	}
+
+	if len(readLockFile.Platforms) != 2 {
+		t.Fatalf("expected 2 platforms to be locked, but got %d", len(readLockFile.Platforms))
+	}
+
+	linuxCloud := readLockFile.Platforms["linux/amd64"][0]
+	if linuxCloud.Location != "sky/cloud-linux-amd64" || linuxCloud.Checksum != "linux_sum" || linuxCloud.Rule != "executable" {
+		t.Errorf("unexpected linux/amd64 entry for bin/cloud: %+v", linuxCloud)
+	}
+
+	darwinCloud := readLockFile.Platforms["darwin/arm64"][0]
+	if darwinCloud.Location != "sky/cloud-darwin-arm64" || darwinCloud.Checksum != "darwin_sum" {
+		t.Errorf("unexpected darwin/arm64 entry for bin/cloud: %+v", darwinCloud)
+	}
+
+	darwinRecord := readLockFile.Platforms["darwin/arm64"][1]
+	if darwinRecord.Rule != "executable_from_archive" || darwinRecord.ArchiveChecksum != "archive_sum" || darwinRecord.ExtractFilepath != "bin/record" || darwinRecord.ExecutableChecksum != "exe_sum" {
+		t.Errorf("unexpected darwin/arm64 entry for bin/record: %+v", darwinRecord)
+	}
+}
+
+func TestCreateReturnsErrorForInvalidPlatform(t *testing.T) {
+	fs := afero.NewMemMapFs()
+
+	if err := afero.WriteFile(fs, "lockal.star", []byte(lockalStar), 0644); err != nil {
+		t.Fatalf("unexpected error while creating lockal.star: %v", err)
+	}
+
+	if _, err := Create(getConfig(fs), []string{"linux"}); err == nil {
+		t.Fatal("expected an error when platform is missing an architecture")
+	}
+}
+
+func TestCheck(t *testing.T) {
+	lockFile := File{
+		Version: version,
+		Platforms: map[string][]Entry{
+			"linux/amd64": {
+				{Name: "bin/a", Rule: "executable", Location: "some.sh/a", Checksum: "a_sum"},
+				{Name: "bin/b", Rule: "executable", Location: "some.sh/b", Checksum: "b_sum"},
+				{Name: "bin/c", Rule: "executable", Location: "some.sh/c", Checksum: "c_sum"},
+			},
+		},
+	}
+
+	matchingDeps := []dependency.Dependency{
+		dependency.Executable{Name: "bin/a", Location: "some.sh/a", Checksum: "a_sum"},
+		dependency.Executable{Name: "bin/b", Location: "some.sh/b", Checksum: "b_sum"},
+		dependency.Executable{Name: "bin/c", Location: "some.sh/c", Checksum: "c_sum"},
+	}
+
+	if err := Check(lockFile, "linux/amd64", matchingDeps); err != nil {
+		t.Errorf("expected no error when deps match lock file, but got %v", err)
+	}
+
+	changedDeps := []dependency.Dependency{
+		dependency.Executable{Name: "bin/a", Location: "some.sh/a", Checksum: "a_sum"},
+		dependency.Executable{Name: "bin/b", Location: "some.sh/b", Checksum: "new_b_sum"},
+		dependency.Executable{Name: "bin/d", Location: "some.sh/d", Checksum: "d_sum"},
+	}
+
+	err := Check(lockFile, "linux/amd64", changedDeps)
+	if err == nil {
+		t.Fatal("expected an error when deps do not match lock file")
+	}
+
+	expectedErrorMessage := "lockal.star does not match lockal.lock for linux/amd64, run lockal lock: bin/b does not match locked version; bin/c is locked but no longer in lockal.star; bin/d is not locked"
+	if err.Error() != expectedErrorMessage {
+		t.Errorf("expected error message of \"%s\", but got \"%s\"", expectedErrorMessage, err.Error())
+	}
+
+	if err := Check(lockFile, "darwin/amd64", matchingDeps); err == nil {
+		t.Error("expected an error when platform is not locked")
+	}
+}
@@ -0,0 +1,114 @@
+package parse
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/spf13/afero"
+	"go.starlark.net/starlark"
+)
+
+type loadEntry struct {
+	globals starlark.StringDict
+	err     error
+}
+
+// loader implements starlark's load statement for lockal.star and any files it loads.
+//
+// Modules starting with // are resolved relative to the directory containing lockal.star,
+// all other modules are resolved relative to the file containing the load statement.
+type loader struct {
+	fs          afero.Fs
+	predeclared starlark.StringDict
+	cache       map[string]*loadEntry
+	inProgress  []string
+}
+
+func newLoader(fs afero.Fs, predeclared starlark.StringDict) *loader {
+	return &loader{
+		fs:          fs,
+		predeclared: predeclared,
+		cache:       map[string]*loadEntry{},
+	}
+}
+
+// exec evaluates the file at filepath and caches its globals so later loads of the same file are not re-evaluated
+func (l *loader) exec(filepath string) (starlark.StringDict, error) {
+	entry, ok := l.cache[filepath]
+	if ok {
+		if entry == nil {
+			return nil, fmt.Errorf("cycle in load graph: %s -> %s", strings.Join(l.inProgress, " -> "), filepath)
+		}
+
+		return entry.globals, entry.err
+	}
+
+	// mark filepath as in progress so cycles can be detected
+	l.cache[filepath] = nil
+	l.inProgress = append(l.inProgress, filepath)
+
+	globals, err := l.execFile(filepath)
+
+	l.inProgress = l.inProgress[:len(l.inProgress)-1]
+	l.cache[filepath] = &loadEntry{globals, err}
+
+	return globals, err
+}
+
+func (l *loader) execFile(filepath string) (starlark.StringDict, error) {
+	fileData, err := afero.ReadFile(l.fs, filepath)
+	if err != nil {
+		return nil, err
+	}
+
+	thread := &starlark.Thread{
+		Name: fmt.Sprintf("lockal-load %s", filepath),
+		Load: l.load,
+	}
+
+	globals, err := starlark.ExecFile(thread, filepath, fileData, l.predeclared)
+
+	return globals, withPosition(err)
+}
+
+func (l *loader) load(thread *starlark.Thread, module string) (starlark.StringDict, error) {
+	filepath, err := resolveModule(thread.CallFrame(0).Pos.Filename(), module)
+	if err != nil {
+		return nil, err
+	}
+
+	return l.exec(filepath)
+}
+
+func resolveModule(fromFilepath, module string) (string, error) {
+	var filepath string
+	if strings.HasPrefix(module, "//") {
+		filepath = path.Clean(strings.TrimPrefix(module, "//"))
+	} else {
+		filepath = path.Join(path.Dir(fromFilepath), module)
+	}
+
+	if filepath == "." || filepath == ".." || strings.HasPrefix(filepath, "../") || path.IsAbs(filepath) {
+		return "", fmt.Errorf("module %s must be a file within the lockal.star directory", module)
+	}
+
+	return filepath, nil
+}
+
+// withPosition prefixes evaluation errors with the file, line, and column where they occurred
+func withPosition(err error) error {
+	evalErr, ok := err.(*starlark.EvalError)
+	if !ok {
+		return err
+	}
+
+	for i := len(evalErr.CallStack) - 1; i >= 0; i-- {
+		pos := evalErr.CallStack[i].Pos
+		if pos.Filename() != "<builtin>" {
+			return fmt.Errorf("%s: %w", pos, evalErr)
+		}
+	}
+
+	return err
+}
@@ -0,0 +1,157 @@
+package parse
+
+import (
+	"testing"
+
+	"github.com/spf13/afero"
+
+	"github.com/dustinspecker/lockal/internal/dependency"
+)
+
+func writeFiles(t *testing.T, fs afero.Fs, files map[string]string) {
+	for filepath, contents := range files {
+		if err := afero.WriteFile(fs, filepath, []byte(contents), 0644); err != nil {
+			t.Fatalf("unexpected error while creating %s: %v", filepath, err)
+		}
+	}
+}
+
+func TestGetDependenciesLoadsModules(t *testing.T) {
+	fs := afero.NewMemMapFs()
+
+	writeFiles(t, fs, map[string]string{
+		"lockal.star": `
+load("//tools/common.star", "get_checksum", "helper_loaded")
+load("//tools/common.star", helper = "helper_loaded")
+
+executable(
+	name = "cat",
+	location = "farm/feline",
+	checksum = get_checksum("cat"),
+)
+`,
+		"tools/common.star": `
+load("checksums.star", "checksums")
+
+helper_loaded = True
+
+def get_checksum(name):
+	return checksums[name]
+`,
+		"tools/checksums.star": `
+checksums = {"cat": "some_sum"}
+`,
+	})
+
+	deps, err := GetDependencies(fs)
+	if err != nil {
+		t.Fatalf("unexpected error when invoking GetDependencies: %v", err)
+	}
+
+	if len(deps) != 1 {
+		t.Fatalf("expected 1 dep to be returned, but got %d", len(deps))
+	}
+
+	dep := deps[0].(dependency.Executable)
+	if dep.Checksum != "some_sum" {
+		t.Errorf("expected dep to have checksum some_sum, but got %s", dep.Checksum)
+	}
+}
+
+func TestGetDependenciesLoadedModulesCanDefineRules(t *testing.T) {
+	fs := afero.NewMemMapFs()
+
+	writeFiles(t, fs, map[string]string{
+		"lockal.star": `
+load("//tools/rules.star", "tool")
+
+tool("cat")
+tool("dog")
+`,
+		"tools/rules.star": `
+def tool(name):
+	executable(
+		name = "bin/" + name,
+		location = "farm/" + name,
+		checksum = name + "_sum",
+	)
+`,
+	})
+
+	deps, err := GetDependencies(fs)
+	if err != nil {
+		t.Fatalf("unexpected error when invoking GetDependencies: %v", err)
+	}
+
+	if len(deps) != 2 {
+		t.Fatalf("expected 2 deps to be returned, but got %d", len(deps))
+	}
+
+	if deps[1].(dependency.Executable).Name != "bin/dog" {
+		t.Errorf("expected second dep to have name bin/dog, but got %s", deps[1].(dependency.Executable).Name)
+	}
+}
+
+func TestGetDependenciesReturnsErrorOnLoadCycle(t *testing.T) {
+	fs := afero.NewMemMapFs()
+
+	writeFiles(t, fs, map[string]string{
+		"lockal.star": `load("//a.star", "a")`,
+		"a.star":      `load("b.star", "b")` + "\na = 1",
+		"b.star":      `load("//a.star", "a")` + "\nb = 1",
+	})
+
+	_, err := GetDependencies(fs)
+	if err == nil {
+		t.Fatal("expected error when load graph has a cycle")
+	}
+
+	expectedErrorMessage := "lockal.star:1:1: cannot load //a.star: a.star:1:1: cannot load b.star: b.star:1:1: cannot load //a.star: cycle in load graph: lockal.star -> a.star -> b.star -> a.star"
+	if err.Error() != expectedErrorMessage {
+		t.Errorf("expected error message of \"%s\", but got \"%s\"", expectedErrorMessage, err.Error())
+	}
+}
+
+func TestGetDependenciesReturnsErrorWithPositionOfFailure(t *testing.T) {
+	fs := afero.NewMemMapFs()
+
+	writeFiles(t, fs, map[string]string{
+		"lockal.star": `
+load("//tools/common.star", "get_checksum")
+
+x = get_checksum("cat")
+`,
+		"tools/common.star": `
+def get_checksum(name):
+	fail("unsupported: " + name)
+`,
+	})
+
+	_, err := GetDependencies(fs)
+	if err == nil {
+		t.Fatal("expected error when loaded function fails")
+	}
+
+	expectedErrorMessage := "tools/common.star:3:6: fail: unsupported: cat"
+	if err.Error() != expectedErrorMessage {
+		t.Errorf("expected error message of \"%s\", but got \"%s\"", expectedErrorMessage, err.Error())
+	}
+}
+
+func TestGetDependenciesReturnsErrorWhenModuleEscapesRoot(t *testing.T) {
+	fs := afero.NewMemMapFs()
+
+	writeFiles(t, fs, map[string]string{
+		"lockal.star": `load("../common.star", "x")`,
+	})
+
+	_, err := GetDependencies(fs)
+	if err == nil {
+		t.Fatal("expected error when module is outside of lockal.star directory")
+	}
+
+	expectedErrorMessage := "lockal.star:1:1: cannot load ../common.star: module ../common.star must be a file within the lockal.star directory"
+	if err.Error() != expectedErrorMessage {
+		t.Errorf("expected error message of \"%s\", but got \"%s\"", expectedErrorMessage, err.Error())
+	}
+}
@@ -1,26 +1,26 @@
 package parse
 
 import (
+	"fmt"
 	"runtime"
 
 	"github.com/spf13/afero"
 	"go.starlark.net/starlark"
+	"go.starlark.net/starlarkstruct"
 
 	"github.com/dustinspecker/lockal/internal/dependency"
 	"github.com/dustinspecker/lockal/internal/rules"
 )
 
 func GetDependencies(fs afero.Fs) ([]dependency.Dependency, error) {
-	deps := []dependency.Dependency{}
+	return GetDependenciesForPlatform(fs, runtime.GOOS, runtime.GOARCH)
+}
 
-	fileData, err := fs.Open("lockal.star")
-	if err != nil {
-		return deps, err
-	}
+// GetDependenciesForPlatform evaluates lockal.star as if lockal were running on operatingSystem and architecture
+func GetDependenciesForPlatform(fs afero.Fs, operatingSystem, architecture string) ([]dependency.Dependency, error) {
+	deps := []dependency.Dependency{}
 
-	thread := &starlark.Thread{
-		Name: "lockal-main",
-	}
+	platform := fmt.Sprintf("%s/%s", operatingSystem, architecture)
 
 	addDep := func(dep dependency.Dependency) error {
 		deps = append(deps, dep)
@@ -29,13 +29,14 @@ func GetDependencies(fs afero.Fs) ([]dependency.Dependency, error) {
 	}
 
 	nativeFunctions := starlark.StringDict{
-		"LOCKAL_ARCH":             starlark.String(runtime.GOARCH),
-		"LOCKAL_OS":               starlark.String(runtime.GOOS),
-		"executable":              starlark.NewBuiltin("executable", rules.Executable(addDep)),
-		"executable_from_archive": starlark.NewBuiltin("executable_from_archive", rules.ExecutableFromArchive(addDep)),
+		"LOCKAL_ARCH":             starlark.String(architecture),
+		"LOCKAL_OS":               starlark.String(operatingSystem),
+		"executable":              starlark.NewBuiltin("executable", rules.Executable(platform, addDep)),
+		"executable_from_archive": starlark.NewBuiltin("executable_from_archive", rules.ExecutableFromArchive(platform, addDep)),
+		"struct":                  starlark.NewBuiltin("struct", starlarkstruct.Make),
 	}
 
-	_, err = starlark.ExecFile(thread, "lockal.star", fileData, nativeFunctions)
+	_, err := newLoader(fs, nativeFunctions).exec("lockal.star")
 
 	return deps, err
 }
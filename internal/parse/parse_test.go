@@ -108,3 +108,89 @@ func TestGetDependencyReturnsErrorIfLockalFileIsInvalid(t *testing.T) {
 		t.Fatalf("expected error when lockal.star file is not valid")
 	}
 }
+
+func TestGetDependenciesForPlatform(t *testing.T) {
+	fs := afero.NewMemMapFs()
+
+	fileContents := `
+executable(
+	name = "cloud",
+	location = "sky/cloud-%(os)s-%(arch)s" % dict(os = LOCKAL_OS, arch = LOCKAL_ARCH),
+	checksum = "another_sum",
+)
+`
+
+	if err := afero.WriteFile(fs, "lockal.star", []byte(fileContents), 0644); err != nil {
+		t.Fatalf("unexpected error while creating lockal.star: %v", err)
+	}
+
+	deps, err := GetDependenciesForPlatform(fs, "plan9", "mips")
+	if err != nil {
+		t.Fatalf("unexpected error when invoking GetDependenciesForPlatform: %v", err)
+	}
+
+	if len(deps) != 1 {
+		t.Fatalf("expected 1 dep to be returned, but got %d", len(deps))
+	}
+
+	dep := deps[0].(dependency.Executable)
+	if dep.Location != "sky/cloud-plan9-mips" {
+		t.Errorf("expected dep to have location sky/cloud-plan9-mips, but got %s", dep.Location)
+	}
+}
+
+func TestGetDependenciesForPlatformSelectsPlatformKeyedArguments(t *testing.T) {
+	fs := afero.NewMemMapFs()
+
+	fileContents := `
+executable(
+	name = "bin/kind",
+	location = "sky/kind-%(os)s-%(arch)s" % dict(os = LOCKAL_OS, arch = LOCKAL_ARCH),
+	checksum = {
+		"linux/amd64": "linux_sum",
+		"darwin": "darwin_sum",
+	},
+)
+
+executable_from_archive(
+	name = "bin/helm",
+	platforms = {
+		"linux/amd64": struct(location = "sky/helm-linux.tgz", archive_checksum = "helm_linux_sum"),
+		"darwin/arm64": dict(location = "sky/helm-darwin.tgz", archive_checksum = "helm_darwin_sum"),
+	},
+	extract_filepath = "helm",
+	executable_checksum = "helm_sum",
+	optional = True,
+)
+`
+
+	if err := afero.WriteFile(fs, "lockal.star", []byte(fileContents), 0644); err != nil {
+		t.Fatalf("unexpected error while creating lockal.star: %v", err)
+	}
+
+	deps, err := GetDependenciesForPlatform(fs, "darwin", "arm64")
+	if err != nil {
+		t.Fatalf("unexpected error when invoking GetDependenciesForPlatform: %v", err)
+	}
+
+	if len(deps) != 2 {
+		t.Fatalf("expected 2 deps to be returned, but got %d", len(deps))
+	}
+
+	if checksum := deps[0].(dependency.Executable).Checksum; checksum != "darwin_sum" {
+		t.Errorf("expected bin/kind to use the checksum for darwin, but got %s", checksum)
+	}
+
+	if location := deps[1].(dependency.ExecutableFromArchive).Location; location != "sky/helm-darwin.tgz" {
+		t.Errorf("expected bin/helm to use the location for darwin/arm64, but got %s", location)
+	}
+
+	deps, err = GetDependenciesForPlatform(fs, "linux", "arm64")
+	if err == nil {
+		t.Fatal("expected an error for a platform without a checksum")
+	}
+
+	if err.Error() != "lockal.star:2:11: executable: checksum has no entry for linux/arm64, add one or set optional = True to skip unsupported platforms" {
+		t.Errorf("unexpected error message: %s", err.Error())
+	}
+}
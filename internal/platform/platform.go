@@ -0,0 +1,141 @@
+package platform
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/spf13/afero"
+)
+
+// Current returns the os/arch lockal is running on, such as linux/amd64
+func Current() string {
+	return fmt.Sprintf("%s/%s", runtime.GOOS, runtime.GOARCH)
+}
+
+// GetDefaultPrefix returns where dependencies for platform are installed, so installing for another platform doesn't
+// replace the executables for the current platform
+func GetDefaultPrefix(platform string) string {
+	if platform == Current() {
+		return ""
+	}
+
+	return filepath.Join(".lockal", "platforms", strings.ReplaceAll(platform, "/", "-"))
+}
+
+// prefixFs resolves relative paths, such as dependency names and lockal's state, within a prefix directory while
+// absolute paths, such as the cache, are left unchanged
+type prefixFs struct {
+	source afero.Fs
+	prefix string
+}
+
+// NewPrefixFs returns source with relative paths resolved within prefix, or source itself when prefix is empty
+func NewPrefixFs(source afero.Fs, prefix string) afero.Fs {
+	if prefix == "" {
+		return source
+	}
+
+	return prefixFs{
+		source: source,
+		prefix: prefix,
+	}
+}
+
+// realPath returns where name is in source
+func (fs prefixFs) realPath(name string) string {
+	if filepath.IsAbs(name) {
+		return name
+	}
+
+	return filepath.Join(fs.prefix, name)
+}
+
+func (fs prefixFs) Create(name string) (afero.File, error) {
+	file, err := fs.source.Create(fs.realPath(name))
+
+	return newPrefixFile(file, name), err
+}
+
+func (fs prefixFs) Mkdir(name string, perm os.FileMode) error {
+	return fs.source.Mkdir(fs.realPath(name), perm)
+}
+
+func (fs prefixFs) MkdirAll(path string, perm os.FileMode) error {
+	return fs.source.MkdirAll(fs.realPath(path), perm)
+}
+
+func (fs prefixFs) Open(name string) (afero.File, error) {
+	file, err := fs.source.Open(fs.realPath(name))
+
+	return newPrefixFile(file, name), err
+}
+
+func (fs prefixFs) OpenFile(name string, flag int, perm os.FileMode) (afero.File, error) {
+	file, err := fs.source.OpenFile(fs.realPath(name), flag, perm)
+
+	return newPrefixFile(file, name), err
+}
+
+func (fs prefixFs) Remove(name string) error {
+	return fs.source.Remove(fs.realPath(name))
+}
+
+func (fs prefixFs) RemoveAll(path string) error {
+	return fs.source.RemoveAll(fs.realPath(path))
+}
+
+func (fs prefixFs) Rename(oldname, newname string) error {
+	return fs.source.Rename(fs.realPath(oldname), fs.realPath(newname))
+}
+
+func (fs prefixFs) Stat(name string) (os.FileInfo, error) {
+	return fs.source.Stat(fs.realPath(name))
+}
+
+func (fs prefixFs) LstatIfPossible(name string) (os.FileInfo, bool, error) {
+	if lstater, ok := fs.source.(afero.Lstater); ok {
+		return lstater.LstatIfPossible(fs.realPath(name))
+	}
+
+	info, err := fs.source.Stat(fs.realPath(name))
+
+	return info, false, err
+}
+
+func (fs prefixFs) Name() string {
+	return "PrefixFs"
+}
+
+func (fs prefixFs) Chmod(name string, mode os.FileMode) error {
+	return fs.source.Chmod(fs.realPath(name), mode)
+}
+
+func (fs prefixFs) Chown(name string, uid, gid int) error {
+	return fs.source.Chown(fs.realPath(name), uid, gid)
+}
+
+func (fs prefixFs) Chtimes(name string, atime time.Time, mtime time.Time) error {
+	return fs.source.Chtimes(fs.realPath(name), atime, mtime)
+}
+
+// prefixFile reports the name it was opened with, so names such as temporary files can be passed back to prefixFs
+type prefixFile struct {
+	afero.File
+	name string
+}
+
+func newPrefixFile(file afero.File, name string) afero.File {
+	if file == nil {
+		return nil
+	}
+
+	return prefixFile{file, name}
+}
+
+func (file prefixFile) Name() string {
+	return file.name
+}
@@ -0,0 +1,53 @@
+package platform
+
+import (
+	"testing"
+
+	"github.com/spf13/afero"
+)
+
+func TestGetDefaultPrefix(t *testing.T) {
+	if prefix := GetDefaultPrefix(Current()); prefix != "" {
+		t.Errorf("expected no prefix for the current platform, but got %s", prefix)
+	}
+
+	if prefix := GetDefaultPrefix("plan9/mips"); prefix != ".lockal/platforms/plan9-mips" {
+		t.Errorf("expected prefix for plan9/mips to be .lockal/platforms/plan9-mips, but got %s", prefix)
+	}
+}
+
+func TestPrefixFs(t *testing.T) {
+	source := afero.NewMemMapFs()
+	fs := NewPrefixFs(source, "prefix")
+
+	if err := afero.WriteFile(fs, "bin/tool", []byte("tool"), 0755); err != nil {
+		t.Fatalf("unexpected error writing bin/tool: %v", err)
+	}
+
+	if err := afero.WriteFile(fs, "/.cache/entry", []byte("entry"), 0644); err != nil {
+		t.Fatalf("unexpected error writing /.cache/entry: %v", err)
+	}
+
+	if _, err := source.Stat("prefix/bin/tool"); err != nil {
+		t.Errorf("expected relative path to be within prefix, but got %v", err)
+	}
+
+	if _, err := source.Stat("/.cache/entry"); err != nil {
+		t.Errorf("expected absolute path to be unchanged, but got %v", err)
+	}
+
+	// temporary files are renamed by the name they report
+	tempFile, err := afero.TempFile(fs, "bin", ".tmp-")
+	if err != nil {
+		t.Fatalf("unexpected error creating temporary file: %v", err)
+	}
+	tempFile.Close()
+
+	if err = fs.Rename(tempFile.Name(), "bin/other"); err != nil {
+		t.Fatalf("unexpected error renaming %s: %v", tempFile.Name(), err)
+	}
+
+	if _, err := source.Stat("prefix/bin/other"); err != nil {
+		t.Errorf("expected renamed file to be within prefix, but got %v", err)
+	}
+}
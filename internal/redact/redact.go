@@ -0,0 +1,138 @@
+package redact
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/apex/log"
+)
+
+// Replacement is written in place of anything redacted
+const Replacement = "REDACTED"
+
+// minSecretLength prevents short values, such as an empty password, from redacting unrelated text
+const minSecretLength = 4
+
+var (
+	urlPattern = regexp.MustCompile(`[a-zA-Z][a-zA-Z0-9+.-]*://[^\s"'<>]+`)
+
+	sensitiveQueryNames = []string{"auth", "credential", "key", "password", "secret", "signature", "token"}
+
+	secrets = struct {
+		sync.RWMutex
+		values map[string]bool
+	}{
+		values: map[string]bool{},
+	}
+)
+
+// AddSecret ensures secret is redacted by String wherever it appears, such as a token sent in a header
+func AddSecret(secret string) {
+	if len(secret) < minSecretLength {
+		return
+	}
+
+	secrets.Lock()
+	defer secrets.Unlock()
+
+	secrets.values[secret] = true
+}
+
+// URL redacts credentials in location's user info and the values of query parameters that look sensitive, such as
+// token or X-Amz-Signature. location is returned unchanged if it can't be parsed.
+func URL(location string) string {
+	parsedURL, err := url.Parse(location)
+	if err != nil {
+		return location
+	}
+
+	redacted := false
+
+	if parsedURL.User != nil {
+		parsedURL.User = url.User(Replacement)
+		redacted = true
+	}
+
+	if parsedURL.RawQuery != "" {
+		params := strings.Split(parsedURL.RawQuery, "&")
+
+		for i, param := range params {
+			name := strings.SplitN(param, "=", 2)[0]
+
+			if isSensitiveQueryName(name) {
+				params[i] = name + "=" + Replacement
+				redacted = true
+			}
+		}
+
+		parsedURL.RawQuery = strings.Join(params, "&")
+	}
+
+	// only re-encode location when needed, so locations without secrets are printed exactly as written
+	if !redacted {
+		return location
+	}
+
+	return parsedURL.String()
+}
+
+// String redacts every URL in s with URL and every secret added with AddSecret
+func String(s string) string {
+	s = urlPattern.ReplaceAllStringFunc(s, URL)
+
+	secrets.RLock()
+	defer secrets.RUnlock()
+
+	for secret := range secrets.values {
+		s = strings.ReplaceAll(s, secret, Replacement)
+	}
+
+	return s
+}
+
+// Handler redacts every log entry's message and fields before passing it to handler
+type Handler struct {
+	handler log.Handler
+}
+
+// NewHandler returns a Handler wrapping handler
+func NewHandler(handler log.Handler) *Handler {
+	return &Handler{handler: handler}
+}
+
+func (h *Handler) HandleLog(entry *log.Entry) error {
+	redactedEntry := *entry
+	redactedEntry.Message = String(entry.Message)
+	redactedEntry.Fields = log.Fields{}
+
+	for name, value := range entry.Fields {
+		switch value := value.(type) {
+		case string:
+			redactedEntry.Fields[name] = String(value)
+		case error:
+			redactedEntry.Fields[name] = String(value.Error())
+		default:
+			redactedEntry.Fields[name] = value
+		}
+	}
+
+	return h.handler.HandleLog(&redactedEntry)
+}
+
+func isSensitiveQueryName(name string) bool {
+	name = strings.ToLower(name)
+
+	if name == "sig" {
+		return true
+	}
+
+	for _, sensitiveName := range sensitiveQueryNames {
+		if strings.Contains(name, sensitiveName) {
+			return true
+		}
+	}
+
+	return false
+}
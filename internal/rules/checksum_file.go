@@ -0,0 +1,29 @@
+package rules
+
+import (
+	"fmt"
+
+	"github.com/dustinspecker/lockal/internal/dependency"
+)
+
+// validateChecksum ensures a rule either provides the checksum named checksumName or reads it from a pinned checksum
+// file
+func validateChecksum(ruleName, checksumName, checksum string, checksumFile dependency.ChecksumFile) error {
+	if checksum != "" && checksumFile.IsSet() {
+		return fmt.Errorf("%s: only one of %s or checksum_url may be provided", ruleName, checksumName)
+	}
+
+	if checksum == "" && !checksumFile.IsSet() {
+		return fmt.Errorf("%s: missing argument for %s or checksum_url", ruleName, checksumName)
+	}
+
+	if checksumFile.IsSet() && checksumFile.Checksum == "" {
+		return fmt.Errorf("%s: checksum_url_checksum must be provided to pin checksum_url", ruleName)
+	}
+
+	if !checksumFile.IsSet() && (checksumFile.Checksum != "" || checksumFile.Entry != "") {
+		return fmt.Errorf("%s: checksum_url_checksum and checksum_entry may only be provided with checksum_url", ruleName)
+	}
+
+	return nil
+}
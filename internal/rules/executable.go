@@ -5,20 +5,41 @@ import (
 	"go.starlark.net/starlark"
 )
 
-func Executable(addDep func(dep dependency.Dependency) error) func(thread *starlark.Thread, builtin *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+func Executable(platform string, addDep func(dep dependency.Dependency) error) func(thread *starlark.Thread, builtin *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
 	return func(thread *starlark.Thread, builtin *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
 		var name string
 		var location string
 		var checksum string
+		var checksumFile dependency.ChecksumFile
+		var signature dependency.Signature
 
-		if err := starlark.UnpackArgs(builtin.Name(), args, kwargs, "name", &name, "location", &location, "checksum", &checksum); err != nil {
+		args, kwargs, skip, err := selectPlatform(builtin.Name(), platform, args, kwargs)
+		if err != nil {
+			return nil, err
+		}
+
+		if skip {
+			return starlark.None, nil
+		}
+
+		if err := starlark.UnpackArgs(builtin.Name(), args, kwargs, "name", &name, "location", &location, "checksum?", &checksum, "checksum_url?", &checksumFile.Location, "checksum_url_checksum?", &checksumFile.Checksum, "checksum_entry?", &checksumFile.Entry, "signature_url?", &signature.Location, "signature_key?", &signature.Key, "signature_key_name?", &signature.KeyName); err != nil {
+			return nil, err
+		}
+
+		if err := validateChecksum(builtin.Name(), "checksum", checksum, checksumFile); err != nil {
+			return nil, err
+		}
+
+		if err := validateSignature(builtin.Name(), signature); err != nil {
 			return nil, err
 		}
 
 		addDep(dependency.Executable{
-			Name:     name,
-			Location: location,
-			Checksum: checksum,
+			Name:         name,
+			Location:     location,
+			Checksum:     checksum,
+			ChecksumFile: checksumFile,
+			Signature:    signature,
 		})
 
 		return starlark.None, nil
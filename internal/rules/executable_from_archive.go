@@ -1,19 +1,49 @@
 package rules
 
 import (
+	"fmt"
+
 	"github.com/dustinspecker/lockal/internal/dependency"
 	"go.starlark.net/starlark"
 )
 
-func ExecutableFromArchive(addDep func(dep dependency.Dependency) error) func(thread *starlark.Thread, builtin *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+func ExecutableFromArchive(platform string, addDep func(dep dependency.Dependency) error) func(thread *starlark.Thread, builtin *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
 	return func(thread *starlark.Thread, builtin *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
 		var name string
 		var location string
 		var archiveChecksum string
 		var extractFilepath string
 		var executableChecksum string
+		var checksumFile dependency.ChecksumFile
+		var signature dependency.Signature
+
+		args, kwargs, skip, err := selectPlatform(builtin.Name(), platform, args, kwargs)
+		if err != nil {
+			return nil, err
+		}
+
+		if skip {
+			return starlark.None, nil
+		}
+
+		if err := starlark.UnpackArgs(builtin.Name(), args, kwargs, "name", &name, "location", &location, "archive_checksum?", &archiveChecksum, "extract_filepath", &extractFilepath, "executable_checksum", &executableChecksum, "checksum_url?", &checksumFile.Location, "checksum_url_checksum?", &checksumFile.Checksum, "checksum_entry?", &checksumFile.Entry, "signature_url?", &signature.Location, "signature_key?", &signature.Key, "signature_key_name?", &signature.KeyName); err != nil {
+			return nil, err
+		}
+
+		// every argument after archive_checksum is optional to UnpackArgs, so required ones are checked here
+		if extractFilepath == "" {
+			return nil, fmt.Errorf("%s: missing argument for extract_filepath", builtin.Name())
+		}
+
+		if executableChecksum == "" {
+			return nil, fmt.Errorf("%s: missing argument for executable_checksum", builtin.Name())
+		}
+
+		if err := validateChecksum(builtin.Name(), "archive_checksum", archiveChecksum, checksumFile); err != nil {
+			return nil, err
+		}
 
-		if err := starlark.UnpackArgs(builtin.Name(), args, kwargs, "name", &name, "location", &location, "archive_checksum", &archiveChecksum, "extract_filepath", &extractFilepath, "executable_checksum", &executableChecksum); err != nil {
+		if err := validateSignature(builtin.Name(), signature); err != nil {
 			return nil, err
 		}
 
@@ -23,6 +53,8 @@ func ExecutableFromArchive(addDep func(dep dependency.Dependency) error) func(th
 			ArchiveChecksum:    archiveChecksum,
 			ExtractFilepath:    extractFilepath,
 			ExecutableChecksum: executableChecksum,
+			ChecksumFile:       checksumFile,
+			Signature:          signature,
 		})
 
 		return starlark.None, nil
@@ -50,7 +50,7 @@ func TestExecutableFromArchive(t *testing.T) {
 		return nil
 	}
 
-	value, err := ExecutableFromArchive(addDep)(thread, builtin, args, kwargs)
+	value, err := ExecutableFromArchive("linux/amd64", addDep)(thread, builtin, args, kwargs)
 	if err != nil {
 		t.Fatalf("unexpected error invoking ExecutableFromArchive: %v", err)
 	}
@@ -74,8 +74,35 @@ func TestExecutableFromArchiveReturnsErrorWhenInvalidArgs(t *testing.T) {
 		return nil
 	}
 
-	_, err := ExecutableFromArchive(addDep)(thread, builtin, args, kwargs)
+	_, err := ExecutableFromArchive("linux/amd64", addDep)(thread, builtin, args, kwargs)
 	if err == nil {
 		t.Fatal("ExecutableFromArchive should have returned an error")
 	}
 }
+
+func TestExecutableFromArchiveReturnsErrorWhenMissingExtractFilepath(t *testing.T) {
+	args := []starlark.Value{
+		starlark.String("some_name"),
+		starlark.String("some_location"),
+	}
+	kwargs := []starlark.Tuple{
+		{starlark.String("checksum_url"), starlark.String("some_checksum_url")},
+		{starlark.String("checksum_url_checksum"), starlark.String("some_checksum_url_checksum")},
+		{starlark.String("executable_checksum"), starlark.String("some_executable_checksum")},
+	}
+
+	addDep := func(dep dependency.Dependency) error {
+		t.Error("addDep should not have been called")
+
+		return nil
+	}
+
+	_, err := ExecutableFromArchive("linux/amd64", addDep)(&starlark.Thread{}, starlark.NewBuiltin("executable_from_archive", nil), args, kwargs)
+	if err == nil {
+		t.Fatal("ExecutableFromArchive should have returned an error")
+	}
+
+	if err.Error() != "executable_from_archive: missing argument for extract_filepath" {
+		t.Errorf("unexpected error message: %s", err.Error())
+	}
+}
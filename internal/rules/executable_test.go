@@ -40,7 +40,7 @@ func TestExecutable(t *testing.T) {
 		return nil
 	}
 
-	value, err := Executable(addDep)(thread, builtin, args, kwargs)
+	value, err := Executable("linux/amd64", addDep)(thread, builtin, args, kwargs)
 	if err != nil {
 		t.Fatalf("unexpected error invoking Executable: %v", err)
 	}
@@ -64,8 +64,122 @@ func TestExecutableReturnsErrorWhenInvalidArgs(t *testing.T) {
 		return nil
 	}
 
-	_, err := Executable(addDep)(thread, builtin, args, kwargs)
+	_, err := Executable("linux/amd64", addDep)(thread, builtin, args, kwargs)
 	if err == nil {
 		t.Fatal("Executable should have returned an error")
 	}
 }
+
+func TestExecutableWithChecksumURL(t *testing.T) {
+	thread := &starlark.Thread{}
+	builtin := starlark.NewBuiltin("executable", nil)
+	args := []starlark.Value{
+		starlark.String("some_name"),
+		starlark.String("some_location"),
+	}
+	kwargs := []starlark.Tuple{
+		{starlark.String("checksum_url"), starlark.String("some_checksum_url")},
+		{starlark.String("checksum_url_checksum"), starlark.String("some_checksum_url_checksum")},
+		{starlark.String("checksum_entry"), starlark.String("some_entry")},
+	}
+
+	var exe dependency.Executable
+
+	addDep := func(dep dependency.Dependency) error {
+		exe = dep.(dependency.Executable)
+
+		return nil
+	}
+
+	if _, err := Executable("linux/amd64", addDep)(thread, builtin, args, kwargs); err != nil {
+		t.Fatalf("unexpected error invoking Executable: %v", err)
+	}
+
+	expectedChecksumFile := dependency.ChecksumFile{Location: "some_checksum_url", Checksum: "some_checksum_url_checksum", Entry: "some_entry"}
+	if exe.ChecksumFile != expectedChecksumFile {
+		t.Errorf("expected exe.ChecksumFile to be %+v, but was %+v", expectedChecksumFile, exe.ChecksumFile)
+	}
+
+	if exe.Checksum != "" {
+		t.Errorf("expected exe.Checksum to be read from checksum_url later, but was %s", exe.Checksum)
+	}
+}
+
+func TestExecutableReturnsErrorForInvalidChecksumArgs(t *testing.T) {
+	tests := map[string][]starlark.Tuple{
+		"executable: missing argument for checksum or checksum_url": {},
+		"executable: only one of checksum or checksum_url may be provided": {
+			{starlark.String("checksum"), starlark.String("some_checksum")},
+			{starlark.String("checksum_url"), starlark.String("some_checksum_url")},
+			{starlark.String("checksum_url_checksum"), starlark.String("some_checksum_url_checksum")},
+		},
+		"executable: checksum_url_checksum must be provided to pin checksum_url": {
+			{starlark.String("checksum_url"), starlark.String("some_checksum_url")},
+		},
+		"executable: checksum_url_checksum and checksum_entry may only be provided with checksum_url": {
+			{starlark.String("checksum"), starlark.String("some_checksum")},
+			{starlark.String("checksum_entry"), starlark.String("some_entry")},
+		},
+	}
+
+	for expectedError, kwargs := range tests {
+		args := []starlark.Value{
+			starlark.String("some_name"),
+			starlark.String("some_location"),
+		}
+
+		addDep := func(dep dependency.Dependency) error {
+			t.Error("addDep should not have been called")
+
+			return nil
+		}
+
+		_, err := Executable("linux/amd64", addDep)(&starlark.Thread{}, starlark.NewBuiltin("executable", nil), args, kwargs)
+		if err == nil {
+			t.Fatalf("expected error %s", expectedError)
+		}
+
+		if err.Error() != expectedError {
+			t.Errorf("expected error %s, but got %s", expectedError, err.Error())
+		}
+	}
+}
+
+func TestExecutableReturnsErrorForInvalidSignatureArgs(t *testing.T) {
+	tests := map[string][]starlark.Tuple{
+		"executable: one of signature_key or signature_key_name must be provided to verify signature_url": {
+			{starlark.String("signature_url"), starlark.String("some_signature_url")},
+		},
+		"executable: only one of signature_key or signature_key_name may be provided": {
+			{starlark.String("signature_url"), starlark.String("some_signature_url")},
+			{starlark.String("signature_key"), starlark.String("some_key")},
+			{starlark.String("signature_key_name"), starlark.String("some_key_name")},
+		},
+		"executable: signature_key and signature_key_name may only be provided with signature_url": {
+			{starlark.String("signature_key"), starlark.String("some_key")},
+		},
+	}
+
+	for expectedError, kwargs := range tests {
+		args := []starlark.Value{
+			starlark.String("some_name"),
+			starlark.String("some_location"),
+			starlark.String("some_checksum"),
+		}
+
+		addDep := func(dep dependency.Dependency) error {
+			t.Error("addDep should not have been called")
+
+			return nil
+		}
+
+		_, err := Executable("linux/amd64", addDep)(&starlark.Thread{}, starlark.NewBuiltin("executable", nil), args, kwargs)
+		if err == nil {
+			t.Fatalf("expected error %s", expectedError)
+		}
+
+		if err.Error() != expectedError {
+			t.Errorf("expected error %s, but got %s", expectedError, err.Error())
+		}
+	}
+}
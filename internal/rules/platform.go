@@ -0,0 +1,156 @@
+package rules
+
+import (
+	"fmt"
+	"strings"
+
+	"go.starlark.net/starlark"
+	"go.starlark.net/starlarkstruct"
+)
+
+// selectPlatform replaces platform-keyed arguments with their value for platform, such as linux/amd64.
+//
+// Any argument may be a dict keyed by platform instead of a value, and the platforms argument is a dict keyed by
+// platform of structs or dicts holding any of the rule's other arguments. Keys may also be only an operating system,
+// such as darwin, to match every architecture. When platform has no entry, an error is returned unless the rule is
+// marked optional, in which case skip is true.
+func selectPlatform(ruleName, platform string, args starlark.Tuple, kwargs []starlark.Tuple) (selectedArgs starlark.Tuple, selectedKwargs []starlark.Tuple, skip bool, err error) {
+	optional := false
+	missing := ""
+
+	selectValue := func(argName string, value starlark.Value) (starlark.Value, error) {
+		dict, ok := value.(*starlark.Dict)
+		if !ok {
+			return value, nil
+		}
+
+		selected, found, err := lookupPlatform(dict, platform)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s: %w", ruleName, argName, err)
+		}
+
+		if !found && missing == "" {
+			missing = argName
+		}
+
+		return selected, nil
+	}
+
+	for i, arg := range args {
+		selected, err := selectValue(fmt.Sprintf("argument %d", i+1), arg)
+		if err != nil {
+			return nil, nil, false, err
+		}
+
+		if selected != nil {
+			selectedArgs = append(selectedArgs, selected)
+		}
+	}
+
+	for _, kwarg := range kwargs {
+		argName := string(kwarg[0].(starlark.String))
+
+		switch argName {
+		case "optional":
+			value, ok := kwarg[1].(starlark.Bool)
+			if !ok {
+				return nil, nil, false, fmt.Errorf("%s: optional must be a bool, but got %s", ruleName, kwarg[1].Type())
+			}
+
+			optional = bool(value)
+		case "platforms":
+			platforms, ok := kwarg[1].(*starlark.Dict)
+			if !ok {
+				return nil, nil, false, fmt.Errorf("%s: platforms must be a dict keyed by os/arch, but got %s", ruleName, kwarg[1].Type())
+			}
+
+			entry, found, err := lookupPlatform(platforms, platform)
+			if err != nil {
+				return nil, nil, false, fmt.Errorf("%s: platforms: %w", ruleName, err)
+			}
+
+			if !found {
+				if missing == "" {
+					missing = argName
+				}
+
+				continue
+			}
+
+			entryKwargs, err := getEntryKwargs(entry)
+			if err != nil {
+				return nil, nil, false, fmt.Errorf("%s: platforms entry for %s: %w", ruleName, platform, err)
+			}
+
+			selectedKwargs = append(selectedKwargs, entryKwargs...)
+		default:
+			selected, err := selectValue(argName, kwarg[1])
+			if err != nil {
+				return nil, nil, false, err
+			}
+
+			if selected != nil {
+				selectedKwargs = append(selectedKwargs, starlark.Tuple{kwarg[0], selected})
+			}
+		}
+	}
+
+	if missing != "" {
+		if optional {
+			return nil, nil, true, nil
+		}
+
+		return nil, nil, false, fmt.Errorf("%s: %s has no entry for %s, add one or set optional = True to skip unsupported platforms", ruleName, missing, platform)
+	}
+
+	return selectedArgs, selectedKwargs, false, nil
+}
+
+// lookupPlatform returns the value in dict for platform, falling back to the value for platform's operating system
+func lookupPlatform(dict *starlark.Dict, platform string) (starlark.Value, bool, error) {
+	for _, key := range dict.Keys() {
+		if _, ok := key.(starlark.String); !ok {
+			return nil, false, fmt.Errorf("expected keys such as linux/amd64, but got %s", key.Type())
+		}
+	}
+
+	operatingSystem := strings.Split(platform, "/")[0]
+
+	for _, key := range []string{platform, operatingSystem} {
+		value, found, err := dict.Get(starlark.String(key))
+		if err != nil || found {
+			return value, found, err
+		}
+	}
+
+	return nil, false, nil
+}
+
+// getEntryKwargs returns the fields of a platforms entry as keyword arguments
+func getEntryKwargs(entry starlark.Value) ([]starlark.Tuple, error) {
+	kwargs := []starlark.Tuple{}
+
+	switch entry := entry.(type) {
+	case *starlarkstruct.Struct:
+		for _, name := range entry.AttrNames() {
+			value, err := entry.Attr(name)
+			if err != nil {
+				return nil, err
+			}
+
+			kwargs = append(kwargs, starlark.Tuple{starlark.String(name), value})
+		}
+	case *starlark.Dict:
+		for _, item := range entry.Items() {
+			if _, ok := item[0].(starlark.String); !ok {
+				return nil, fmt.Errorf("expected argument names as keys, but got %s", item[0].Type())
+			}
+
+			kwargs = append(kwargs, item)
+		}
+	default:
+		return nil, fmt.Errorf("expected a struct or dict, but got %s", entry.Type())
+	}
+
+	return kwargs, nil
+}
@@ -0,0 +1,82 @@
+package rules
+
+import (
+	"testing"
+
+	"go.starlark.net/starlark"
+	"go.starlark.net/starlarkstruct"
+)
+
+func newPlatformDict(t *testing.T, entries map[string]starlark.Value) *starlark.Dict {
+	t.Helper()
+
+	dict := starlark.NewDict(len(entries))
+	for key, value := range entries {
+		if err := dict.SetKey(starlark.String(key), value); err != nil {
+			t.Fatalf("unexpected error creating dict: %v", err)
+		}
+	}
+
+	return dict
+}
+
+func TestSelectPlatform(t *testing.T) {
+	args := starlark.Tuple{
+		starlark.String("some_name"),
+		newPlatformDict(t, map[string]starlark.Value{"linux/amd64": starlark.String("linux_location")}),
+	}
+	kwargs := []starlark.Tuple{
+		{starlark.String("platforms"), newPlatformDict(t, map[string]starlark.Value{
+			"linux": starlarkstruct.FromStringDict(starlarkstruct.Default, starlark.StringDict{"checksum": starlark.String("linux_sum")}),
+		})},
+		{starlark.String("optional"), starlark.True},
+	}
+
+	selectedArgs, selectedKwargs, skip, err := selectPlatform("executable", "linux/amd64", args, kwargs)
+	if err != nil {
+		t.Fatalf("unexpected error invoking selectPlatform: %v", err)
+	}
+
+	if skip {
+		t.Error("expected rule to not be skipped")
+	}
+
+	if len(selectedArgs) != 2 || selectedArgs[1] != starlark.String("linux_location") {
+		t.Errorf("expected location to be selected for linux/amd64, but got %v", selectedArgs)
+	}
+
+	if len(selectedKwargs) != 1 || selectedKwargs[0][0] != starlark.String("checksum") || selectedKwargs[0][1] != starlark.String("linux_sum") {
+		t.Errorf("expected checksum to be selected from the platforms entry for linux, but got %v", selectedKwargs)
+	}
+}
+
+func TestSelectPlatformSkipsOptionalRule(t *testing.T) {
+	kwargs := []starlark.Tuple{
+		{starlark.String("checksum"), newPlatformDict(t, map[string]starlark.Value{"linux/amd64": starlark.String("linux_sum")})},
+		{starlark.String("optional"), starlark.True},
+	}
+
+	_, _, skip, err := selectPlatform("executable", "windows/amd64", starlark.Tuple{}, kwargs)
+	if err != nil {
+		t.Fatalf("unexpected error invoking selectPlatform: %v", err)
+	}
+
+	if !skip {
+		t.Error("expected optional rule to be skipped for a platform without an entry")
+	}
+}
+
+func TestSelectPlatformReturnsErrorForMissingPlatform(t *testing.T) {
+	kwargs := []starlark.Tuple{
+		{starlark.String("platforms"), newPlatformDict(t, map[string]starlark.Value{"linux/amd64": starlark.NewDict(0)})},
+	}
+
+	_, _, _, err := selectPlatform("executable", "windows/amd64", starlark.Tuple{}, kwargs)
+	if err == nil {
+		t.Fatal("expected an error for a platform without an entry")
+	}
+
+	if err.Error() != "executable: platforms has no entry for windows/amd64, add one or set optional = True to skip unsupported platforms" {
+		t.Errorf("unexpected error message: %s", err.Error())
+	}
+}
@@ -0,0 +1,28 @@
+package rules
+
+import (
+	"fmt"
+
+	"github.com/dustinspecker/lockal/internal/dependency"
+)
+
+// validateSignature ensures a rule requiring a signature provides exactly one public key to verify it with
+func validateSignature(ruleName string, signature dependency.Signature) error {
+	if !signature.IsSet() {
+		if signature.Key != "" || signature.KeyName != "" {
+			return fmt.Errorf("%s: signature_key and signature_key_name may only be provided with signature_url", ruleName)
+		}
+
+		return nil
+	}
+
+	if signature.Key == "" && signature.KeyName == "" {
+		return fmt.Errorf("%s: one of signature_key or signature_key_name must be provided to verify signature_url", ruleName)
+	}
+
+	if signature.Key != "" && signature.KeyName != "" {
+		return fmt.Errorf("%s: only one of signature_key or signature_key_name may be provided", ruleName)
+	}
+
+	return nil
+}
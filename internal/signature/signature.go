@@ -0,0 +1,178 @@
+package signature
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+
+	"golang.org/x/crypto/blake2b"
+)
+
+const (
+	// minisignAlgorithm signs the file itself, which is also what signify does
+	minisignAlgorithm = "Ed"
+	// minisignPrehashedAlgorithm signs the BLAKE2b-512 of the file, which minisign does by default
+	minisignPrehashedAlgorithm = "ED"
+
+	untrustedCommentPrefix = "untrusted comment:"
+	trustedCommentPrefix   = "trusted comment: "
+)
+
+// Verify returns an error unless signature is a valid signature of content made with publicKey.
+//
+// publicKey is either a minisign or signify public key, such as RWQf6LRCGA9i53mlYecO4IzT51TGPpvWucNSCh1CBM0QTaLn73Y7GFO3
+// or the content of a .pub file, or a PEM encoded ECDSA public key as used by cosign. signature is the content of the
+// matching detached signature file.
+func Verify(publicKey string, signature []byte, content io.Reader) error {
+	if strings.Contains(publicKey, "-----BEGIN") {
+		return verifyECDSA(publicKey, signature, content)
+	}
+
+	return verifyMinisign(publicKey, signature, content)
+}
+
+// verifyECDSA verifies a base64 encoded ASN.1 signature of the SHA-256 of content, as created by cosign sign-blob
+func verifyECDSA(publicKey string, signature []byte, content io.Reader) error {
+	block, _ := pem.Decode([]byte(publicKey))
+	if block == nil {
+		return fmt.Errorf("unable to decode PEM public key")
+	}
+
+	parsedKey, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return fmt.Errorf("parsing public key: %w", err)
+	}
+
+	ecdsaKey, ok := parsedKey.(*ecdsa.PublicKey)
+	if !ok {
+		return fmt.Errorf("unsupported public key type %T, expected an ECDSA public key", parsedKey)
+	}
+
+	// cosign writes signatures base64 encoded, but accept raw ASN.1 signatures too
+	decodedSignature, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(signature)))
+	if err != nil {
+		decodedSignature = signature
+	}
+
+	contentHash := sha256.New()
+	if _, err = io.Copy(contentHash, content); err != nil {
+		return err
+	}
+
+	if !ecdsa.VerifyASN1(ecdsaKey, contentHash.Sum(nil), decodedSignature) {
+		return fmt.Errorf("signature does not match public key")
+	}
+
+	return nil
+}
+
+// verifyMinisign verifies a minisign signature, which includes signify signatures since they share the same format
+// without a trusted comment
+func verifyMinisign(publicKey string, signature []byte, content io.Reader) error {
+	keyLines := getLines([]byte(publicKey))
+	if len(keyLines) == 0 {
+		return fmt.Errorf("empty public key")
+	}
+
+	key, err := base64.StdEncoding.DecodeString(keyLines[len(keyLines)-1])
+	if err != nil || len(key) != 2+8+ed25519.PublicKeySize || string(key[:2]) != minisignAlgorithm {
+		return fmt.Errorf("invalid minisign or signify public key")
+	}
+
+	keyID, edKey := key[2:10], ed25519.PublicKey(key[10:])
+
+	signatureLines := getLines(signature)
+	if len(signatureLines) != 1 && len(signatureLines) != 3 {
+		return fmt.Errorf("invalid minisign or signify signature, expected a signature optionally followed by a trusted comment and global signature")
+	}
+
+	decodedSignature, err := base64.StdEncoding.DecodeString(signatureLines[0])
+	if err != nil || len(decodedSignature) != 2+8+ed25519.SignatureSize {
+		return fmt.Errorf("invalid minisign or signify signature")
+	}
+
+	algorithm, signatureKeyID, edSignature := string(decodedSignature[:2]), decodedSignature[2:10], decodedSignature[10:]
+
+	if !bytes.Equal(keyID, signatureKeyID) {
+		return fmt.Errorf("signature was made with key %X, not the provided key %X", reverse(signatureKeyID), reverse(keyID))
+	}
+
+	var message []byte
+
+	switch algorithm {
+	case minisignAlgorithm:
+		if message, err = ioutil.ReadAll(content); err != nil {
+			return err
+		}
+	case minisignPrehashedAlgorithm:
+		contentHash, _ := blake2b.New512(nil)
+		if _, err = io.Copy(contentHash, content); err != nil {
+			return err
+		}
+
+		message = contentHash.Sum(nil)
+	default:
+		return fmt.Errorf("unsupported signature algorithm %s", algorithm)
+	}
+
+	if !ed25519.Verify(edKey, message, edSignature) {
+		return fmt.Errorf("signature does not match public key")
+	}
+
+	if len(signatureLines) == 1 {
+		return nil
+	}
+
+	// the global signature covers the trusted comment, so it can't be modified
+	if !strings.HasPrefix(signatureLines[1], trustedCommentPrefix) {
+		return fmt.Errorf("invalid minisign signature, expected a trusted comment")
+	}
+
+	globalSignature, err := base64.StdEncoding.DecodeString(signatureLines[2])
+	if err != nil || len(globalSignature) != ed25519.SignatureSize {
+		return fmt.Errorf("invalid minisign global signature")
+	}
+
+	trustedComment := strings.TrimPrefix(signatureLines[1], trustedCommentPrefix)
+
+	if !ed25519.Verify(edKey, append(append([]byte{}, edSignature...), trustedComment...), globalSignature) {
+		return fmt.Errorf("trusted comment signature does not match public key")
+	}
+
+	return nil
+}
+
+// getLines returns the non-empty lines of content other than untrusted comments
+func getLines(content []byte) []string {
+	lines := []string{}
+
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+
+		if line != "" && !strings.HasPrefix(line, untrustedCommentPrefix) {
+			lines = append(lines, line)
+		}
+	}
+
+	return lines
+}
+
+// reverse returns a key ID in the order minisign prints it
+func reverse(keyID []byte) []byte {
+	reversed := make([]byte, len(keyID))
+	for i := range keyID {
+		reversed[len(keyID)-1-i] = keyID[i]
+	}
+
+	return reversed
+}
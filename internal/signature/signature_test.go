@@ -0,0 +1,147 @@
+package signature
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/blake2b"
+)
+
+var keyID = []byte{1, 2, 3, 4, 5, 6, 7, 8}
+
+// newMinisignKey returns a minisign public key file and its private key
+func newMinisignKey(t *testing.T) (string, ed25519.PrivateKey) {
+	t.Helper()
+
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("unexpected error generating key: %v", err)
+	}
+
+	key := append(append([]byte(minisignAlgorithm), keyID...), publicKey...)
+
+	return fmt.Sprintf("untrusted comment: minisign public key\n%s\n", base64.StdEncoding.EncodeToString(key)), privateKey
+}
+
+// signMinisign returns a minisign signature of content, or a signify signature when trustedComment is empty
+func signMinisign(privateKey ed25519.PrivateKey, algorithm string, content []byte, trustedComment string) []byte {
+	message := content
+	if algorithm == minisignPrehashedAlgorithm {
+		hash := blake2b.Sum512(content)
+		message = hash[:]
+	}
+
+	signature := ed25519.Sign(privateKey, message)
+	encoded := base64.StdEncoding.EncodeToString(append(append([]byte(algorithm), keyID...), signature...))
+
+	if trustedComment == "" {
+		return []byte(fmt.Sprintf("untrusted comment: verify with key.pub\n%s\n", encoded))
+	}
+
+	globalSignature := ed25519.Sign(privateKey, append(signature, trustedComment...))
+
+	return []byte(fmt.Sprintf("untrusted comment: signature from minisign secret key\n%s\ntrusted comment: %s\n%s\n", encoded, trustedComment, base64.StdEncoding.EncodeToString(globalSignature)))
+}
+
+func TestVerifyMinisign(t *testing.T) {
+	publicKey, privateKey := newMinisignKey(t)
+	content := []byte("file a")
+
+	signatures := map[string][]byte{
+		"prehashed": signMinisign(privateKey, minisignPrehashedAlgorithm, content, "timestamp:1609459200"),
+		"legacy":    signMinisign(privateKey, minisignAlgorithm, content, "timestamp:1609459200"),
+		"signify":   signMinisign(privateKey, minisignAlgorithm, content, ""),
+	}
+
+	for name, signature := range signatures {
+		if err := Verify(publicKey, signature, bytes.NewReader(content)); err != nil {
+			t.Errorf("expected %s signature to be valid, but got %v", name, err)
+		}
+
+		if err := Verify(publicKey, signature, bytes.NewReader([]byte("file b"))); err == nil {
+			t.Errorf("expected %s signature of a different file to be invalid", name)
+		}
+	}
+
+	// the bare key, as printed by minisign -P, is also accepted
+	bareKey := strings.Split(strings.TrimSpace(publicKey), "\n")[1]
+	if err := Verify(bareKey, signatures["prehashed"], bytes.NewReader(content)); err != nil {
+		t.Errorf("expected signature to be valid with the bare key, but got %v", err)
+	}
+}
+
+func TestVerifyMinisignRejectsModifiedTrustedComment(t *testing.T) {
+	publicKey, privateKey := newMinisignKey(t)
+	content := []byte("file a")
+
+	signature := signMinisign(privateKey, minisignPrehashedAlgorithm, content, "timestamp:1609459200")
+	signature = bytes.Replace(signature, []byte("timestamp:1609459200"), []byte("timestamp:1609459201"), 1)
+
+	err := Verify(publicKey, signature, bytes.NewReader(content))
+	if err == nil {
+		t.Fatal("expected an error for a modified trusted comment")
+	}
+
+	if err.Error() != "trusted comment signature does not match public key" {
+		t.Errorf("unexpected error message: %s", err.Error())
+	}
+}
+
+func TestVerifyMinisignRejectsSignatureFromAnotherKey(t *testing.T) {
+	publicKey, _ := newMinisignKey(t)
+	_, otherPrivateKey := newMinisignKey(t)
+	content := []byte("file a")
+
+	err := Verify(publicKey, signMinisign(otherPrivateKey, minisignPrehashedAlgorithm, content, "comment"), bytes.NewReader(content))
+	if err == nil {
+		t.Fatal("expected an error for a signature from another key")
+	}
+
+	if err.Error() != "signature does not match public key" {
+		t.Errorf("unexpected error message: %s", err.Error())
+	}
+}
+
+func TestVerifyECDSA(t *testing.T) {
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("unexpected error generating key: %v", err)
+	}
+
+	derKey, err := x509.MarshalPKIXPublicKey(&privateKey.PublicKey)
+	if err != nil {
+		t.Fatalf("unexpected error marshaling key: %v", err)
+	}
+
+	publicKey := string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: derKey}))
+
+	content := []byte("file a")
+	hash := sha256.Sum256(content)
+
+	signature, err := ecdsa.SignASN1(rand.Reader, privateKey, hash[:])
+	if err != nil {
+		t.Fatalf("unexpected error signing: %v", err)
+	}
+
+	if err = Verify(publicKey, []byte(base64.StdEncoding.EncodeToString(signature)+"\n"), bytes.NewReader(content)); err != nil {
+		t.Errorf("expected base64 signature to be valid, but got %v", err)
+	}
+
+	if err = Verify(publicKey, signature, bytes.NewReader(content)); err != nil {
+		t.Errorf("expected raw signature to be valid, but got %v", err)
+	}
+
+	if err = Verify(publicKey, signature, bytes.NewReader([]byte("file b"))); err == nil {
+		t.Error("expected signature of a different file to be invalid")
+	}
+}
@@ -0,0 +1,234 @@
+package state
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/apex/log"
+	"github.com/spf13/afero"
+
+	"github.com/dustinspecker/lockal/internal/dependency"
+	"github.com/dustinspecker/lockal/internal/lock"
+)
+
+const (
+	Filepath = ".lockal/state.json"
+	// LockFilepath is locked while installing or removing files, so lockal processes in the same project take turns
+	LockFilepath = ".lockal/install.lock"
+	version      = 1
+)
+
+// Entry is a file installed by lockal
+type Entry struct {
+	Name     string     `json:"name"`
+	Checksum string     `json:"checksum"`
+	Rule     lock.Entry `json:"rule"`
+}
+
+// State records every file installed by lockal, keyed by name
+type State struct {
+	Version int              `json:"version"`
+	Files   map[string]Entry `json:"files"`
+}
+
+// Read returns the recorded state, or an empty state if lockal hasn't installed anything yet
+func Read(fs afero.Fs) (State, error) {
+	st := State{
+		Version: version,
+		Files:   map[string]Entry{},
+	}
+
+	content, err := afero.ReadFile(fs, Filepath)
+	if os.IsNotExist(err) {
+		return st, nil
+	}
+	if err != nil {
+		return st, err
+	}
+
+	if err = json.Unmarshal(content, &st); err != nil {
+		return st, fmt.Errorf("parsing %s: %w", Filepath, err)
+	}
+
+	if st.Version != version {
+		return st, fmt.Errorf("unsupported %s version %d, expected %d", Filepath, st.Version, version)
+	}
+
+	if st.Files == nil {
+		st.Files = map[string]Entry{}
+	}
+
+	return st, nil
+}
+
+// Write saves st by renaming a temporary file, so an interrupted write never leaves a partial state file
+func Write(fs afero.Fs, st State) error {
+	content, err := json.MarshalIndent(st, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err = fs.MkdirAll(filepath.Dir(Filepath), 0755); err != nil {
+		return err
+	}
+
+	tempFile, err := afero.TempFile(fs, filepath.Dir(Filepath), "state.json.")
+	if err != nil {
+		return err
+	}
+	defer fs.Remove(tempFile.Name())
+
+	_, err = tempFile.Write(append(content, '\n'))
+	if err == nil {
+		err = tempFile.Sync()
+	}
+
+	if closeErr := tempFile.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err != nil {
+		return err
+	}
+
+	return fs.Rename(tempFile.Name(), Filepath)
+}
+
+// Record adds each installed dependency to st
+func Record(st State, installed []dependency.Dependency) (State, error) {
+	entries, err := lock.GetEntries(installed)
+	if err != nil {
+		return st, err
+	}
+
+	for _, entry := range entries {
+		st.Files[entry.Name] = Entry{
+			Name:     entry.Name,
+			Checksum: entry.InstalledChecksum(),
+			Rule:     entry,
+		}
+	}
+
+	return st, nil
+}
+
+// Prune removes every recorded file that no longer has a rule in deps
+func Prune(fs afero.Fs, logCtx *log.Entry, st State, deps []dependency.Dependency) (State, error) {
+	ruleNames := map[string]bool{}
+	for _, dep := range deps {
+		ruleNames[dep.GetName()] = true
+	}
+
+	names := []string{}
+	for name := range st.Files {
+		if !ruleNames[name] {
+			names = append(names, name)
+		}
+	}
+
+	sort.Strings(names)
+
+	for _, name := range names {
+		if err := Remove(fs, logCtx, st.Files[name]); err != nil {
+			return st, err
+		}
+
+		delete(st.Files, name)
+	}
+
+	return st, nil
+}
+
+// Uninstall removes the files installed for deps, or only the deps named in names when names isn't empty
+func Uninstall(fs afero.Fs, logCtx *log.Entry, st State, deps []dependency.Dependency, names []string) (State, error) {
+	entries, err := lock.GetEntries(deps)
+	if err != nil {
+		return st, err
+	}
+
+	entriesByName := map[string]lock.Entry{}
+	for _, entry := range entries {
+		entriesByName[entry.Name] = entry
+	}
+
+	if len(names) == 0 {
+		for _, entry := range entries {
+			names = append(names, entry.Name)
+		}
+	}
+
+	for _, name := range names {
+		entry, ok := entriesByName[name]
+		if !ok {
+			return st, fmt.Errorf("no rule named %s in lockal.star", name)
+		}
+
+		installedEntry, ok := st.Files[name]
+		if !ok {
+			// installed before lockal recorded state, so fall back to what lockal.star expects
+			installedEntry = Entry{
+				Name:     name,
+				Checksum: entry.InstalledChecksum(),
+				Rule:     entry,
+			}
+		}
+
+		if err = Remove(fs, logCtx, installedEntry); err != nil {
+			return st, err
+		}
+
+		delete(st.Files, name)
+	}
+
+	return st, nil
+}
+
+// Remove deletes the file recorded by entry if it hasn't been modified since lockal installed it, along with any
+// directories that are empty afterwards
+func Remove(fs afero.Fs, logCtx *log.Entry, entry Entry) error {
+	logCtx = logCtx.WithField("dependency", entry.Name)
+
+	status, err := dependency.VerifyFile(fs, entry.Name, entry.Checksum)
+	if err != nil {
+		return err
+	}
+
+	switch status {
+	case dependency.StatusMissing:
+		logCtx.Info(fmt.Sprintf("forgetting %s since it no longer exists", entry.Name))
+	case dependency.StatusChecksumMismatch:
+		logCtx.Warn(fmt.Sprintf("leaving %s since it was modified after lockal installed it", entry.Name))
+	default:
+		logCtx.Info(fmt.Sprintf("removing %s", entry.Name))
+
+		if err = fs.Remove(entry.Name); err != nil {
+			return err
+		}
+
+		return removeEmptyDirs(fs, filepath.Dir(entry.Name))
+	}
+
+	return nil
+}
+
+// removeEmptyDirs removes dir and its parents until reaching one that isn't empty, it never leaves the project directory
+func removeEmptyDirs(fs afero.Fs, dir string) error {
+	for dir != "." && !filepath.IsAbs(dir) && !strings.HasPrefix(dir, "..") {
+		empty, err := afero.IsEmpty(fs, dir)
+		if err != nil || !empty {
+			return err
+		}
+
+		if err = fs.Remove(dir); err != nil {
+			return err
+		}
+
+		dir = filepath.Dir(dir)
+	}
+
+	return nil
+}
@@ -0,0 +1,192 @@
+package state
+
+import (
+	"os"
+	"testing"
+
+	"github.com/apex/log"
+	"github.com/apex/log/handlers/memory"
+	"github.com/spf13/afero"
+
+	"github.com/dustinspecker/lockal/internal/dependency"
+)
+
+const checksum = "a705aaf587ddc9ed135d4c318c339f3a0d6eb3a2e11936942afbfcd65254da6a1600b7b8e27f59464219fdc704f3b96c9953d80c05632411f475eea6f4548963"
+
+func getLogCtx() *log.Entry {
+	log.SetLevel(log.DebugLevel)
+	log.SetHandler(memory.New())
+
+	return log.WithFields(log.Fields{
+		"app": "lockal-test",
+	})
+}
+
+func TestReadReturnsEmptyStateWhenNotFound(t *testing.T) {
+	st, err := Read(afero.NewMemMapFs())
+	if err != nil {
+		t.Fatalf("unexpected error when invoking Read: %v", err)
+	}
+
+	if len(st.Files) != 0 {
+		t.Errorf("expected no files in state, but got %d", len(st.Files))
+	}
+}
+
+func TestRecordWriteAndRead(t *testing.T) {
+	fs := afero.NewMemMapFs()
+
+	st, err := Read(fs)
+	if err != nil {
+		t.Fatalf("unexpected error when invoking Read: %v", err)
+	}
+
+	installed := []dependency.Dependency{
+		dependency.Executable{Name: "bin/a", Location: "some.sh/a", Checksum: "a_sum"},
+		dependency.ExecutableFromArchive{Name: "bin/b", Location: "some.sh/b.tgz", ArchiveChecksum: "archive_sum", ExtractFilepath: "b", ExecutableChecksum: "b_sum"},
+	}
+
+	if st, err = Record(st, installed); err != nil {
+		t.Fatalf("unexpected error when invoking Record: %v", err)
+	}
+
+	if err = Write(fs, st); err != nil {
+		t.Fatalf("unexpected error when invoking Write: %v", err)
+	}
+
+	readState, err := Read(fs)
+	if err != nil {
+		t.Fatalf("unexpected error when invoking Read: %v", err)
+	}
+
+	if readState.Files["bin/a"].Checksum != "a_sum" || readState.Files["bin/a"].Rule.Rule != "executable" {
+		t.Errorf("unexpected state for bin/a: %+v", readState.Files["bin/a"])
+	}
+
+	if readState.Files["bin/b"].Checksum != "b_sum" || readState.Files["bin/b"].Rule.Location != "some.sh/b.tgz" {
+		t.Errorf("unexpected state for bin/b: %+v", readState.Files["bin/b"])
+	}
+}
+
+func TestPrune(t *testing.T) {
+	fs := afero.NewMemMapFs()
+
+	for _, name := range []string{"bin/kept", "bin/removed"} {
+		if err := afero.WriteFile(fs, name, []byte("file a"), 0755); err != nil {
+			t.Fatalf("unexpected error creating %s: %v", name, err)
+		}
+	}
+
+	if err := afero.WriteFile(fs, "bin/modified", []byte("file a, but modified"), 0755); err != nil {
+		t.Fatalf("unexpected error creating bin/modified: %v", err)
+	}
+
+	st := State{
+		Version: version,
+		Files: map[string]Entry{
+			"bin/kept":     {Name: "bin/kept", Checksum: checksum},
+			"bin/removed":  {Name: "bin/removed", Checksum: checksum},
+			"bin/modified": {Name: "bin/modified", Checksum: checksum},
+			"bin/missing":  {Name: "bin/missing", Checksum: checksum},
+		},
+	}
+
+	deps := []dependency.Dependency{
+		dependency.Executable{Name: "bin/kept", Location: "some.sh/kept", Checksum: checksum},
+	}
+
+	st, err := Prune(fs, getLogCtx(), st, deps)
+	if err != nil {
+		t.Fatalf("unexpected error when invoking Prune: %v", err)
+	}
+
+	if len(st.Files) != 1 {
+		t.Errorf("expected only bin/kept to remain in state, but got %+v", st.Files)
+	}
+
+	if _, err = fs.Stat("bin/kept"); err != nil {
+		t.Errorf("expected bin/kept to exist, but got %v", err)
+	}
+
+	if _, err = fs.Stat("bin/removed"); !os.IsNotExist(err) {
+		t.Errorf("expected bin/removed to be removed, but got %v", err)
+	}
+
+	if _, err = fs.Stat("bin/modified"); err != nil {
+		t.Errorf("expected bin/modified to be left alone, but got %v", err)
+	}
+}
+
+func TestUninstall(t *testing.T) {
+	fs := afero.NewMemMapFs()
+
+	for _, name := range []string{"bin/tools/a", "bin/b", "bin/script.sh"} {
+		if err := afero.WriteFile(fs, name, []byte("file a"), 0755); err != nil {
+			t.Fatalf("unexpected error creating %s: %v", name, err)
+		}
+	}
+
+	if err := afero.WriteFile(fs, "bin/modified", []byte("file a, but modified"), 0755); err != nil {
+		t.Fatalf("unexpected error creating bin/modified: %v", err)
+	}
+
+	st := State{
+		Version: version,
+		Files: map[string]Entry{
+			"bin/tools/a":  {Name: "bin/tools/a", Checksum: checksum},
+			"bin/modified": {Name: "bin/modified", Checksum: checksum},
+		},
+	}
+
+	deps := []dependency.Dependency{
+		dependency.Executable{Name: "bin/tools/a", Location: "some.sh/a", Checksum: checksum},
+		dependency.Executable{Name: "bin/b", Location: "some.sh/b", Checksum: checksum},
+		dependency.Executable{Name: "bin/modified", Location: "some.sh/modified", Checksum: checksum},
+	}
+
+	st, err := Uninstall(fs, getLogCtx(), st, deps, []string{"bin/tools/a"})
+	if err != nil {
+		t.Fatalf("unexpected error when invoking Uninstall: %v", err)
+	}
+
+	if _, err = fs.Stat("bin/tools"); !os.IsNotExist(err) {
+		t.Errorf("expected empty bin/tools directory to be removed, but got %v", err)
+	}
+
+	if _, err = fs.Stat("bin/b"); err != nil {
+		t.Errorf("expected bin/b to not be uninstalled when not named, but got %v", err)
+	}
+
+	if st, err = Uninstall(fs, getLogCtx(), st, deps, nil); err != nil {
+		t.Fatalf("unexpected error when invoking Uninstall: %v", err)
+	}
+
+	if _, err = fs.Stat("bin/b"); !os.IsNotExist(err) {
+		t.Errorf("expected bin/b to be removed even though it wasn't recorded, but got %v", err)
+	}
+
+	if _, err = fs.Stat("bin/modified"); err != nil {
+		t.Errorf("expected bin/modified to be left alone, but got %v", err)
+	}
+
+	if _, err = fs.Stat("bin/script.sh"); err != nil {
+		t.Errorf("expected bin/script.sh to be left alone, but got %v", err)
+	}
+
+	if len(st.Files) != 0 {
+		t.Errorf("expected no files to remain in state, but got %+v", st.Files)
+	}
+}
+
+func TestUninstallReturnsErrorForUnknownName(t *testing.T) {
+	st := State{Version: version, Files: map[string]Entry{}}
+
+	_, err := Uninstall(afero.NewMemMapFs(), getLogCtx(), st, nil, []string{"bin/unknown"})
+	if err == nil {
+		t.Fatal("expected an error when uninstalling an unknown rule")
+	}
+
+	if err.Error() != "no rule named bin/unknown in lockal.star" {
+		t.Errorf("unexpected error message: %s", err.Error())
+	}
+}
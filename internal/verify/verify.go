@@ -0,0 +1,41 @@
+package verify
+
+import (
+	"fmt"
+
+	"github.com/dustinspecker/lockal/internal/config"
+	"github.com/dustinspecker/lockal/internal/dependency"
+)
+
+// Run reports the status of each installed dependency without modifying anything. An error is
+// returned if any dependency is not installed as expected.
+func Run(cfg config.Config, deps []dependency.Dependency) error {
+	invalidDeps := 0
+
+	for _, dep := range deps {
+		logCtx := cfg.LogCtx.WithField("dependency", dep.GetName())
+
+		status, err := dep.Verify(cfg)
+		if err != nil {
+			return fmt.Errorf("%s: %w", dep.GetName(), err)
+		}
+
+		logCtx = logCtx.WithField("status", status)
+
+		if status == dependency.StatusOK {
+			logCtx.Info(fmt.Sprintf("%s is installed", dep.GetName()))
+
+			continue
+		}
+
+		invalidDeps++
+
+		logCtx.Error(fmt.Sprintf("%s is not installed as expected", dep.GetName()))
+	}
+
+	if invalidDeps > 0 {
+		return fmt.Errorf("%d of %d dependencies are not installed as expected", invalidDeps, len(deps))
+	}
+
+	return nil
+}
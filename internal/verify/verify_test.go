@@ -0,0 +1,108 @@
+package verify
+
+import (
+	"testing"
+
+	"github.com/apex/log"
+	"github.com/apex/log/handlers/memory"
+	"github.com/spf13/afero"
+
+	"github.com/dustinspecker/lockal/internal/config"
+	"github.com/dustinspecker/lockal/internal/dependency"
+)
+
+func getConfig(fs afero.Fs) (*memory.Handler, config.Config) {
+	log.SetLevel(log.DebugLevel)
+	logHandler := memory.New()
+	log.SetHandler(logHandler)
+
+	cfg := config.Config{
+		Fs: fs,
+		LogCtx: log.WithFields(log.Fields{
+			"app": "lockal-test",
+		}),
+	}
+
+	return logHandler, cfg
+}
+
+func hasStatus(handler *memory.Handler, name string, status dependency.Status) bool {
+	for _, entry := range handler.Entries {
+		if entry.Fields["dependency"] == name && entry.Fields["status"] == status {
+			return true
+		}
+	}
+
+	return false
+}
+
+func TestRun(t *testing.T) {
+	fs := afero.NewMemMapFs()
+
+	if err := afero.WriteFile(fs, "bin/ok", []byte("file a"), 0755); err != nil {
+		t.Fatalf("unexpected error creating bin/ok: %v", err)
+	}
+
+	if err := afero.WriteFile(fs, "bin/stale", []byte("old file a"), 0755); err != nil {
+		t.Fatalf("unexpected error creating bin/stale: %v", err)
+	}
+
+	logHandler, cfg := getConfig(afero.NewReadOnlyFs(fs))
+
+	checksum := "a705aaf587ddc9ed135d4c318c339f3a0d6eb3a2e11936942afbfcd65254da6a1600b7b8e27f59464219fdc704f3b96c9953d80c05632411f475eea6f4548963"
+
+	deps := []dependency.Dependency{
+		dependency.Executable{Name: "bin/ok", Location: "some.sh/ok", Checksum: checksum},
+		dependency.Executable{Name: "bin/stale", Location: "some.sh/stale", Checksum: checksum},
+		dependency.ExecutableFromArchive{Name: "bin/missing", Location: "some.sh/missing.tgz", ExecutableChecksum: checksum},
+	}
+
+	err := Run(cfg, deps)
+	if err == nil {
+		t.Fatal("expected an error when dependencies are not installed as expected")
+	}
+
+	expectedErrorMessage := "2 of 3 dependencies are not installed as expected"
+	if err.Error() != expectedErrorMessage {
+		t.Errorf("expected error message of \"%s\", but got \"%s\"", expectedErrorMessage, err.Error())
+	}
+
+	if !hasStatus(logHandler, "bin/ok", dependency.StatusOK) {
+		t.Error("expected bin/ok to be reported as ok")
+	}
+
+	if !hasStatus(logHandler, "bin/stale", dependency.StatusChecksumMismatch) {
+		t.Error("expected bin/stale to be reported as checksum-mismatch")
+	}
+
+	if !hasStatus(logHandler, "bin/missing", dependency.StatusMissing) {
+		t.Error("expected bin/missing to be reported as missing")
+	}
+
+	content, err := afero.ReadFile(fs, "bin/stale")
+	if err != nil {
+		t.Fatalf("unexpected error reading bin/stale: %v", err)
+	}
+
+	if string(content) != "old file a" {
+		t.Error("expected bin/stale to not be modified")
+	}
+}
+
+func TestRunReturnsNoErrorWhenEverythingIsInstalled(t *testing.T) {
+	fs := afero.NewMemMapFs()
+
+	if err := afero.WriteFile(fs, "bin/ok", []byte("file a"), 0755); err != nil {
+		t.Fatalf("unexpected error creating bin/ok: %v", err)
+	}
+
+	_, cfg := getConfig(fs)
+
+	deps := []dependency.Dependency{
+		dependency.Executable{Name: "bin/ok", Location: "some.sh/ok", Checksum: "a705aaf587ddc9ed135d4c318c339f3a0d6eb3a2e11936942afbfcd65254da6a1600b7b8e27f59464219fdc704f3b96c9953d80c05632411f475eea6f4548963"},
+	}
+
+	if err := Run(cfg, deps); err != nil {
+		t.Fatalf("expected no error, but got %v", err)
+	}
+}